@@ -3,43 +3,54 @@ package main
 import (
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
-	"os"
 )
 
-func migrateAccountLevelEntities(*cli.Context) error {
+func migrateAccountLevelEntities(ctx *cli.Context) error {
 	log.Info("Migrating all account level entities like secret managers, secrets, connectors.")
-	promptConfirm := PromptDefaultInputs()
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	// Based on the scopes of entities determine the destination details
-	promptConfirm = PromptOrgAndProject([]string{migrationReq.SecretScope, migrationReq.ConnectorScope}) || promptConfirm
+	promptConfirm, err = PromptOrgAndProject([]string{migrationReq.SecretScope, migrationReq.ConnectorScope}, promptConfirm)
+	if err != nil {
+		return err
+	}
 	logMigrationDetails()
 
 	// We confirm if they wish to proceed or not
 	if promptConfirm {
 		confirm := ConfirmInput("Do you wish to proceed importing all secret managers, secrets & connectors?")
 		if !confirm {
-			os.Exit(1)
+			return &AbortedByUser{}
 		}
 	}
 
 	// Create Secret Managers
 	log.Info("Importing all secret managers from CG to NG...")
-	CreateEntities(getReqBody(SecretManager, Filter{
+	if err := createEntities(ctx.Context, SecretManager, Filter{
 		Type: All,
-	}))
+	}); err != nil {
+		return err
+	}
 	log.Info("Imported all secret managers.")
 
 	// Create Secrets
 	log.Info("Importing all secrets from CG to NG...")
-	CreateEntities(getReqBody(Secret, Filter{
+	if err := createEntities(ctx.Context, Secret, Filter{
 		Type: All,
-	}))
+	}); err != nil {
+		return err
+	}
 	log.Info("Imported all secrets.")
 
 	// Create Connectors
 	log.Info("Importing all connectors from CG to NG....")
-	CreateEntities(getReqBody(Connector, Filter{
+	if err := createEntities(ctx.Context, Connector, Filter{
 		Type: All,
-	}))
+	}); err != nil {
+		return err
+	}
 	log.Info("Imported all connectors.")
 
 	return nil
@@ -5,44 +5,65 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func migrateApp(*cli.Context) error {
-	promptConfirm := PromptDefaultInputs()
+func migrateApp(ctx *cli.Context) error {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
 		promptConfirm = true
-		migrationReq.AppId = TextInput("Please provide the application ID of the app that you wish to import -")
+		migrationReq.AppId, err = TextInput("Please provide the application ID of the app that you wish to import -")
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.WorkflowScope) == 0 {
 		promptConfirm = true
-		migrationReq.WorkflowScope = SelectInput("Scope for workflows:", scopes, Project)
+		migrationReq.WorkflowScope, err = SelectInput("Scope for workflows:", scopes, Project)
+		if err != nil {
+			return err
+		}
 	}
 
-	promptConfirm = PromptOrgAndProject([]string{Project}) || promptConfirm
+	promptConfirm, err = PromptOrgAndProject([]string{Project}, promptConfirm)
+	if err != nil {
+		return err
+	}
 
 	logMigrationDetails()
 
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with app migration?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	// Migrating the app
 	log.Info("Importing the application....")
 	log.Info("Importing the services, environments, infra, manifests...")
-	CreateEntities(getReqBody(Application, Filter{
+	err = createEntities(ctx.Context, Application, Filter{
 		AppId: migrationReq.AppId,
-	}))
+	})
+	if err != nil {
+		return err
+	}
 	if migrationReq.AllAppEntities {
 		log.Info("Importing all the workflows...")
-		CreateEntities(getReqBody(Workflow, Filter{
+		err = createEntities(ctx.Context, Workflow, Filter{
 			AppId: migrationReq.AppId,
-		}))
+		})
+		if err != nil {
+			return err
+		}
 		log.Info("Importing all the pipelines...")
-		CreateEntities(getReqBody(Pipeline, Filter{
+		err = createEntities(ctx.Context, Pipeline, Filter{
 			AppId: migrationReq.AppId,
-		}))
+		})
+		if err != nil {
+			return err
+		}
 	}
 	log.Info("Imported the application.")
 
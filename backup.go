@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// ExpressionsManifest records the runs that replaced expressions so that they can be reverted one at a time, the
+// last run first
+type ExpressionsManifest struct {
+	Runs []ExpressionsRun `json:"runs"`
+}
+
+// ExpressionsRun records the files changed by a run
+type ExpressionsRun struct {
+	CreatedAt time.Time      `json:"createdAt"`
+	Files     []ReplacedFile `json:"files"`
+}
+
+type ReplacedFile struct {
+	Path   string `json:"path"`
+	Backup string `json:"backup"`
+	// Hash of the replaced content. Files changed after the replacement are not reverted unless forced.
+	Hash string `json:"hash"`
+}
+
+func getManifestFile() string {
+	return filepath.Join(StateDir, "expressions-manifest.json")
+}
+
+// getBackupDir is where the original files are kept when no backup suffix is given
+func getBackupDir() string {
+	return filepath.Join(StateDir, "expressions-backup")
+}
+
+// getRunBackupDir is where the original files of the nth run are kept
+func getRunBackupDir(run int) string {
+	return filepath.Join(getBackupDir(), strconv.Itoa(run))
+}
+
+func hashContent(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+// startExpressionsRun adds a new run to the manifest. It is called right before the first file of the run is
+// replaced, so runs that change nothing leave the earlier runs revertible.
+func startExpressionsRun() (*ExpressionsManifest, error) {
+	manifest, err := readExpressionsManifest()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", getManifestFile(), err)
+	}
+	manifest.Runs = append(manifest.Runs, ExpressionsRun{CreatedAt: time.Now()})
+	runDir := getRunBackupDir(len(manifest.Runs))
+	// Drop the backups left behind by a run that was reverted
+	if err = os.RemoveAll(runDir); err != nil {
+		return nil, fmt.Errorf("failed to remove the previous backups: %w", err)
+	}
+	for _, dir := range []string{StateDir, getBackupDir(), runDir} {
+		if err = MkDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	return &manifest, manifest.save()
+}
+
+func readExpressionsManifest() (manifest ExpressionsManifest, err error) {
+	content, err := os.ReadFile(getManifestFile())
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &manifest)
+	return
+}
+
+func (m *ExpressionsManifest) save() error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(getManifestFile(), content)
+}
+
+// isBackup reports if the file is the backup of a file in any of the runs
+func (m *ExpressionsManifest) isBackup(path string) bool {
+	for _, run := range m.Runs {
+		for _, file := range run.Files {
+			if file.Backup == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backup keeps the original content of the file before it is replaced & records it in the last run. With a suffix
+// the backup is written next to the file, unless an earlier run keeps its backup there.
+func (m *ExpressionsManifest) backup(path string, original string, replaced string) error {
+	run := &m.Runs[len(m.Runs)-1]
+	backup := filepath.Join(getRunBackupDir(len(m.Runs)), strconv.Itoa(len(run.Files))+"-"+filepath.Base(path))
+	if len(migrationReq.BackupSuffix) > 0 && !m.isBackup(path+migrationReq.BackupSuffix) {
+		backup = path + migrationReq.BackupSuffix
+	}
+	if err := WriteFileAtomic(backup, []byte(original)); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	run.Files = append(run.Files, ReplacedFile{Path: path, Backup: backup, Hash: hashContent(replaced)})
+	return m.save()
+}
+
+// RevertExpressions restores the files changed by the last run that replaced expressions from their backups. Earlier
+// runs are reverted by running it again.
+func RevertExpressions(*cli.Context) error {
+	manifest, err := readExpressionsManifest()
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(manifest.Runs) == 0) {
+		return &ValidationError{Message: "No replacement of expressions found to revert"}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", getManifestFile(), err)
+	}
+
+	run := manifest.Runs[len(manifest.Runs)-1]
+	var changed []string
+	for i := len(run.Files) - 1; i >= 0; i-- {
+		file := run.Files[i]
+		original, err := ReadFile(file.Backup)
+		if err != nil {
+			return fmt.Errorf("failed to read the backup of %s: %w", file.Path, err)
+		}
+		current, err := ReadFile(file.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil && current == original {
+			continue
+		}
+		if (err != nil || hashContent(current) != file.Hash) && !migrationReq.Force {
+			log.Warnf("%s was changed after the expressions were replaced. Skipping it", file.Path)
+			changed = append(changed, file.Path)
+			continue
+		}
+		if err := WriteFileAtomic(file.Path, []byte(original)); err != nil {
+			return fmt.Errorf("failed to revert %s: %w", file.Path, err)
+		}
+		log.Infof("Reverted %s", file.Path)
+	}
+	if len(changed) > 0 {
+		return &ValidationError{Message: fmt.Sprintf("%d files were changed after the expressions were replaced & were not reverted. Pass --force to revert them anyway", len(changed))}
+	}
+
+	for _, file := range run.Files {
+		if err := os.Remove(file.Backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.WithError(err).Warnf("Failed to remove the backup %s", file.Backup)
+		}
+	}
+	if err := os.RemoveAll(getRunBackupDir(len(manifest.Runs))); err != nil {
+		log.WithError(err).Warn("Failed to remove the backups")
+	}
+	manifest.Runs = manifest.Runs[:len(manifest.Runs)-1]
+	if len(manifest.Runs) == 0 {
+		if err := os.RemoveAll(getBackupDir()); err != nil {
+			log.WithError(err).Warn("Failed to remove the backups")
+		}
+		if err := os.Remove(getManifestFile()); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", getManifestFile(), err)
+		}
+	} else if err := manifest.save(); err != nil {
+		return err
+	}
+	log.Infof("Reverted the replacement of expressions from %s", run.CreatedAt.Format(time.RFC1123))
+	if len(manifest.Runs) > 0 {
+		log.Infof("%d earlier replacements can be reverted by running revert again", len(manifest.Runs))
+	}
+	return nil
+}
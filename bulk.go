@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// BulkApp is an app read from one of the files generated by the bulk project creation along with its outcome
+type BulkApp struct {
+	File    string
+	AppId   string
+	Org     string
+	Project string
+	Bodies  []RequestBody
+	Outcome string
+	Err     error
+	Summary SaveSummary
+}
+
+func MigrateBulk(ctx *cli.Context) error {
+	if err := assertNotBlank(migrationReq.ExportFolderPath, "Provide the folder with the generated files using --from"); err != nil {
+		return err
+	}
+	if migrationReq.Parallelism < 1 {
+		return &ValidationError{Message: "--parallelism should be at least 1"}
+	}
+	files, err := getBulkFiles(migrationReq.ExportFolderPath)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return &ValidationError{Message: fmt.Sprintf("No yaml files were found in %s", migrationReq.ExportFolderPath)}
+	}
+	configs := make([]map[string]string, len(files))
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err = yaml.Unmarshal(content, &configs[i]); err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid file %s - %s", file, err)}
+		}
+	}
+	// The generated files hold the environment details as well. Flags take precedence over them.
+	migrationReq.Environment = getOrDefault(migrationReq.Environment, configs[0]["env"])
+	migrationReq.Account = getOrDefault(migrationReq.Account, configs[0]["account"])
+	migrationReq.Auth = getOrDefault(migrationReq.Auth, configs[0]["api-key"])
+
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+
+	apps, err := getBulkApps(ctx.Context, files, configs)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"Account":     migrationReq.Account,
+		"Folder":      migrationReq.ExportFolderPath,
+		"Apps":        len(apps),
+		"Parallelism": migrationReq.Parallelism,
+	}).Info("Bulk migration details")
+	if promptConfirm {
+		confirm := ConfirmInput("Do you want to proceed with the migration of all the apps?")
+		if !confirm {
+			return &AbortedByUser{}
+		}
+	}
+
+	jobs := make(chan *BulkApp)
+	var wg sync.WaitGroup
+	for i := 0; i < migrationReq.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for app := range jobs {
+				migrateBulkApp(ctx.Context, app)
+			}
+		}()
+	}
+	for _, app := range apps {
+		if ctx.Context.Err() != nil {
+			break
+		}
+		jobs <- app
+	}
+	close(jobs)
+	wg.Wait()
+
+	renderBulkResults(apps)
+	var total SaveSummary
+	var failed int
+	var firstErr error
+	for _, app := range apps {
+		total = mergeSaveSummaries(total, app.Summary)
+		if app.Err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = app.Err
+			}
+		}
+	}
+	renderSaveSummary(total)
+	if firstErr == nil && ctx.Context.Err() != nil {
+		return ctx.Context.Err()
+	}
+	if firstErr != nil {
+		return fmt.Errorf("failed to migrate %d of %d apps: %w", failed, len(apps), firstErr)
+	}
+	return nil
+}
+
+func getBulkFiles(folder string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the folder %s: %w", folder, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		files = append(files, filepath.Join(folder, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// getBulkApps builds the requests of every app upfront. The request bodies are built from the global migrationReq, so
+// this is not safe to do from the workers.
+func getBulkApps(ctx context.Context, files []string, configs []map[string]string) ([]*BulkApp, error) {
+	var apps []*BulkApp
+	var problems []string
+	// Scopes given by flags take precedence over the ones in the files
+	secretScope, connectorScope := migrationReq.SecretScope, migrationReq.ConnectorScope
+	templateScope, workflowScope := migrationReq.TemplateScope, migrationReq.WorkflowScope
+	for i, config := range configs {
+		if account := config["account"]; len(account) > 0 && account != migrationReq.Account {
+			problems = append(problems, fmt.Sprintf("%s: the account %s does not match the account %s being migrated", files[i], account, migrationReq.Account))
+			continue
+		}
+		app := &BulkApp{File: files[i], AppId: config["app"], Org: config["org"], Project: config["project"]}
+		if len(app.AppId) == 0 || len(app.Org) == 0 || len(app.Project) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: app, org & project are required", files[i]))
+			continue
+		}
+		migrationReq.AppId = app.AppId
+		migrationReq.OrgIdentifier = app.Org
+		migrationReq.ProjectIdentifier = app.Project
+		migrationReq.SecretScope = getOrDefault(secretScope, config["secret-scope"])
+		migrationReq.ConnectorScope = getOrDefault(connectorScope, config["connector-scope"])
+		migrationReq.TemplateScope = getOrDefault(templateScope, config["template-scope"])
+		migrationReq.WorkflowScope = getOrDefault(workflowScope, config["workflow-scope"])
+		entityTypes := []EntityType{Application}
+		if migrationReq.AllAppEntities {
+			entityTypes = append(entityTypes, Workflow, Pipeline)
+		}
+		for _, entityType := range entityTypes {
+			body, err := getReqBody(ctx, entityType, Filter{AppId: app.AppId})
+			if err != nil {
+				return nil, err
+			}
+			app.Bodies = append(app.Bodies, body)
+		}
+		apps = append(apps, app)
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			log.Error(problem)
+		}
+		return nil, &ValidationError{Message: fmt.Sprintf("%d of the files in %s are invalid", len(problems), migrationReq.ExportFolderPath)}
+	}
+	return apps, nil
+}
+
+// migrateBulkApp migrates the entities of an app one after the other. Progress is logged per app instead of
+// rendering a spinner as multiple apps are migrated at the same time.
+func migrateBulkApp(ctx context.Context, app *BulkApp) {
+	logger := log.WithFields(log.Fields{
+		"app":     app.AppId,
+		"project": app.Project,
+	})
+	for _, body := range app.Bodies {
+		logger.Infof("Importing %s", strings.ToLower(string(body.EntityType)))
+		err := runSaveStep(ctx, body, func(ctx context.Context, reqId string) error {
+			summary, err := pollQuietly(ctx, logger, reqId)
+			app.Summary = mergeSaveSummaries(app.Summary, summary)
+			return err
+		})
+		if err != nil {
+			logger.WithError(err).Error("Failed to migrate the app")
+			app.Outcome = StepOutcomeFailed
+			app.Err = err
+			return
+		}
+	}
+	logger.Info("Imported the app")
+	app.Outcome = StepOutcomeDone
+}
+
+func pollQuietly(ctx context.Context, logger *log.Entry, reqId string) (SaveSummary, error) {
+	start := time.Now()
+	logger = logger.WithField("requestId", reqId)
+	for {
+		if err := sleepWithContext(ctx, time.Second*10); err != nil {
+			return SaveSummary{}, interrupted(reqId, SaveRequest, err)
+		}
+		resource, done, err := getSaveResult(ctx, reqId)
+		if err != nil {
+			if ctx.Err() != nil {
+				return SaveSummary{}, interrupted(reqId, SaveRequest, ctx.Err())
+			}
+			return SaveSummary{}, err
+		}
+		if done {
+			return completeSaveRequest(reqId, resource)
+		}
+		logger.Infof("Processing for %s", time.Since(start).Round(time.Second))
+	}
+}
+
+func renderBulkResults(apps []*BulkApp) {
+	var rows []table.Row
+	for _, app := range apps {
+		var migrated, alreadyMigrated int64
+		for _, v := range app.Summary.Stats {
+			migrated += v.SuccessfullyMigrated
+			alreadyMigrated += v.AlreadyMigrated
+		}
+		message := ""
+		if app.Err != nil {
+			message = app.Err.Error()
+		}
+		rows = append(rows, table.Row{app.AppId, app.Org, app.Project, getOrDefault(app.Outcome, StepOutcomeSkipped), migrated, alreadyMigrated, len(app.Summary.Errors), message})
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"App", "Org", "Project", "Outcome", "Successfully Migrated", "Already Migrated", "Errors", "Message"})
+	t.AppendRows(rows)
+	t.SetStyle(table.StyleLight)
+	t.Render()
+}
@@ -2,33 +2,54 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	log "github.com/sirupsen/logrus"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
-func Post(reqUrl string, auth string, body interface{}) (respBodyObj ResponseBody, err error) {
+// maxRetryDelay caps both the computed backoff & any Retry-After value sent by the server
+const maxRetryDelay = 2 * time.Minute
+
+var retryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+func Post(ctx context.Context, reqUrl string, auth string, body interface{}) (respBodyObj ResponseBody, err error) {
 	postBody, _ := json.Marshal(body)
 	requestBody := bytes.NewBuffer(postBody)
 	log.WithFields(log.Fields{
 		"url":  reqUrl,
 		"body": string(postBody),
 	}).Trace("The request details")
-	req, err := http.NewRequest("POST", reqUrl, requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqUrl, requestBody)
 	if err != nil {
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set(AuthHeaderKey(auth), auth)
-	return handleResp(req)
+	return handleResp(req, false)
 }
 
-func Get(reqUrl string, auth string) (respBodyObj ResponseBody, err error) {
-	req, err := http.NewRequest("GET", reqUrl, nil)
+func Get(ctx context.Context, reqUrl string, auth string) (respBodyObj ResponseBody, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqUrl, nil)
 	if err != nil {
 		return
 	}
@@ -37,10 +58,10 @@ func Get(reqUrl string, auth string) (respBodyObj ResponseBody, err error) {
 	}).Trace("The request details")
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set(AuthHeaderKey(auth), auth)
-	return handleResp(req)
+	return handleResp(req, true)
 }
 
-func Delete(reqUrl string, auth string, body interface{}) (respBodyObj ResponseBody, err error) {
+func Delete(ctx context.Context, reqUrl string, auth string, body interface{}) (respBodyObj ResponseBody, err error) {
 	var requestBody *bytes.Buffer
 	if body != nil {
 		postBody, _ := json.Marshal(body)
@@ -56,9 +77,9 @@ func Delete(reqUrl string, auth string, body interface{}) (respBodyObj ResponseB
 	}
 	var req *http.Request
 	if requestBody != nil {
-		req, err = http.NewRequest("DELETE", reqUrl, requestBody)
+		req, err = http.NewRequestWithContext(ctx, "DELETE", reqUrl, requestBody)
 	} else {
-		req, err = http.NewRequest("DELETE", reqUrl, nil)
+		req, err = http.NewRequestWithContext(ctx, "DELETE", reqUrl, nil)
 	}
 	if err != nil {
 		return
@@ -68,19 +89,11 @@ func Delete(reqUrl string, auth string, body interface{}) (respBodyObj ResponseB
 	if requestBody == nil {
 		req.ContentLength = 0
 	}
-	return handleResp(req)
+	return handleResp(req, true)
 }
 
-func handleResp(req *http.Request) (respBodyObj ResponseBody, err error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	respBody, err := io.ReadAll(resp.Body)
+func handleResp(req *http.Request, retryable bool) (respBodyObj ResponseBody, err error) {
+	resp, respBody, err := doWithRetry(req, retryable)
 	if err != nil {
 		return
 	}
@@ -89,20 +102,143 @@ func handleResp(req *http.Request) (respBodyObj ResponseBody, err error) {
 	}).Trace("The response body")
 	err = json.Unmarshal(respBody, &respBodyObj)
 	if err != nil {
-		log.Fatalln("There was error while parsing the response from server. Exiting...", err)
+		return respBodyObj, &APIError{
+			StatusCode: resp.StatusCode,
+			Url:        req.URL.String(),
+			Message:    "there was error while parsing the response from server",
+		}
 	}
 	if resp.StatusCode != 200 {
-		if len(respBodyObj.Message) > 0 {
-			log.Error(respBodyObj.Message)
-		} else if len(respBodyObj.Messages) > 0 && len(respBodyObj.Messages[0].Message) > 0 {
-			log.Error(respBodyObj.Messages[0].Message)
+		return respBodyObj, &APIError{
+			StatusCode: resp.StatusCode,
+			Url:        req.URL.String(),
+			Message:    respBodyObj.Message,
+			Messages:   respBodyObj.Messages,
 		}
-		return respBodyObj, errors.New("received non 200 response code. The response code was " + strconv.Itoa(resp.StatusCode))
 	}
 
 	return respBodyObj, nil
 }
 
+// doWithRetry executes the request & reads the complete response body. Only idempotent requests are retried, on
+// throttling, gateway errors & dropped connections, waiting with exponential backoff & jitter between attempts.
+func doWithRetry(req *http.Request, retryable bool) (resp *http.Response, respBody []byte, err error) {
+	client := &http.Client{}
+	maxRetries := 0
+	if retryable {
+		maxRetries = migrationReq.MaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return
+			}
+		}
+		if err = waitForRateLimit(req.Context(), req.URL.String()); err != nil {
+			return
+		}
+		resp, err = client.Do(req)
+		if err == nil {
+			respBody, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}
+		if attempt >= maxRetries || !shouldRetry(resp, err) {
+			return
+		}
+		delay := retryDelay(attempt, resp)
+		fields := log.Fields{
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"delay":   delay.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = resp.StatusCode
+		}
+		log.WithFields(fields).Warn("Request failed, retrying")
+		if err = sleepWithContext(req.Context(), delay); err != nil {
+			return
+		}
+	}
+}
+
+// sleepWithContext waits for the given duration & returns early with the context error if it is cancelled
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if err != nil {
+		var netErr net.Error
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF) ||
+			(errors.As(err, &netErr) && netErr.Timeout())
+	}
+	for _, code := range retryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+	backoff := migrationReq.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	delay := backoff << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// Equal jitter: wait at least half the backoff so retries from parallel runs spread out without collapsing to zero
+	jitter.Lock()
+	defer jitter.Unlock()
+	return delay/2 + time.Duration(jitter.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter supports both forms of the header, delay in seconds & an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		delay = time.Until(at)
+	} else {
+		return 0, false
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay, true
+}
+
 func AuthHeaderKey(auth string) string {
 	if strings.HasPrefix(auth, "Bearer ") {
 		return "Authorization"
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile holds named contexts with the values of the global flags to use in each of them
+type ConfigFile struct {
+	CurrentContext string                       `yaml:"currentContext"`
+	Contexts       map[string]map[string]string `yaml:"contexts"`
+}
+
+// contextFlags are the global flags that can be set in a context
+var contextFlags = []string{
+	"env", "base-url", "account", "api-key", "target-account", "target-api-key", "target-gateway-url", "org", "project",
+	"secret-scope", "connector-scope", "workflow-scope", "template-scope", "user-group-scope", "identifier-format",
+}
+
+// contextFlagValues are the allowed values of the flags of a context that only take some values, besides the scopes
+var contextFlagValues = map[string][]string{
+	"env":               {Dev, QA, Prod, "Prod1", "Prod2", Prod3, SelfManaged},
+	"identifier-format": {CamelCaseFormat, LowerCaseFormat},
+}
+
+// getConfigFile returns the path of the config file, which is in $XDG_CONFIG_HOME or else in ~/.config
+func getConfigFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the config file: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "harness-upgrade", "config.yaml"), nil
+}
+
+func readConfigFile() (config ConfigFile, err error) {
+	filePath, err := getConfigFile()
+	if err != nil {
+		return
+	}
+	content, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read the config file: %w", err)
+	}
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return config, &ValidationError{Message: fmt.Sprintf("invalid config file %s - %s", filePath, err)}
+	}
+	return config, nil
+}
+
+// save writes the config file. The file is only readable by the user as contexts may hold api keys.
+func (c *ConfigFile) save() error {
+	filePath, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return fmt.Errorf("failed to create the folder of the config file: %w", err)
+	}
+	if _, err = os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+		if err = os.WriteFile(filePath, nil, 0600); err != nil {
+			return fmt.Errorf("failed to create the config file: %w", err)
+		}
+	}
+	return WriteFileAtomic(filePath, content)
+}
+
+// applyConfigContext sets the global flags from the context given by --context or else the current context. Flags
+// that are set explicitly, by their environment variables or by --load take precedence.
+func applyConfigContext(c *cli.Context) error {
+	config, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	name := getOrDefault(migrationReq.ConfigContext, config.CurrentContext)
+	if len(name) == 0 {
+		return nil
+	}
+	values, ok := config.Contexts[name]
+	if !ok {
+		// The config commands create the context
+		if len(migrationReq.ConfigContext) > 0 && c.Args().First() != "config" {
+			return &ValidationError{Message: fmt.Sprintf("No context named %s found. Use config get-contexts to list them", name)}
+		}
+		return nil
+	}
+	for _, key := range sortedKeys(values) {
+		if !slices.Contains(contextFlags, key) {
+			log.Warnf("Ignoring %s of the context %s as it cannot be set in a context", key, name)
+			continue
+		}
+		if c.IsSet(key) {
+			continue
+		}
+		if err = c.Set(key, values[key]); err != nil {
+			return fmt.Errorf("failed to set %s from the context %s: %w", key, name, err)
+		}
+	}
+	log.Debugf("Using the context %s", name)
+	return nil
+}
+
+// UseConfigContext makes the context the one used when --context is not given
+func UseConfigContext(ctx *cli.Context) error {
+	name := ctx.Args().First()
+	if len(name) == 0 {
+		return &ValidationError{Message: "Provide the name of the context as in config use-context NAME"}
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Contexts[name]; !ok {
+		return &ValidationError{Message: fmt.Sprintf("No context named %s found. Use config get-contexts to list them", name)}
+	}
+	config.CurrentContext = name
+	if err = config.save(); err != nil {
+		return err
+	}
+	log.Infof("Switched to the context %s", name)
+	return nil
+}
+
+// GetConfigContexts lists the contexts of the config file
+func GetConfigContexts(*cli.Context) error {
+	config, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if len(config.Contexts) == 0 {
+		log.Info("No contexts found. Use --context NAME config set KEY VALUE to add one")
+		return nil
+	}
+	names := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var rows []table.Row
+	for _, name := range names {
+		current := ""
+		if name == config.CurrentContext {
+			current = "*"
+		}
+		values := config.Contexts[name]
+		rows = append(rows, table.Row{current, name, values["env"], values["base-url"], values["account"], values["target-account"]})
+	}
+	renderPlanTable(table.Row{"Current", "Name", "Env", "Base URL", "Account", "Target Account"}, rows)
+	return nil
+}
+
+// SetConfigValue sets a flag in the context given by --context or else the current context. The context is created
+// if it does not exist & becomes the current context if there is none.
+func SetConfigValue(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		return &ValidationError{Message: "Provide the flag & its value as in config set KEY VALUE"}
+	}
+	key, value := ctx.Args().Get(0), ctx.Args().Get(1)
+	if err := assertAllowedValues(key, contextFlags, fmt.Sprintf("%s cannot be set in a context. Possible values - %s", key, strings.Join(contextFlags, ", "))); err != nil {
+		return err
+	}
+	if strings.HasSuffix(key, "-scope") {
+		if err := assertAllowedValues(value, scopes, fmt.Sprintf("Invalid scope %s. Possible values - %s", value, strings.Join(scopes, ", "))); err != nil {
+			return err
+		}
+	}
+	if allowed, ok := contextFlagValues[key]; ok {
+		if err := assertAllowedValues(value, allowed, fmt.Sprintf("Invalid %s %s. Possible values - %s", key, value, strings.Join(allowed, ", "))); err != nil {
+			return err
+		}
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	name := getOrDefault(migrationReq.ConfigContext, config.CurrentContext)
+	if len(name) == 0 {
+		return &ValidationError{Message: "No current context. Use --context to name the context to set the flag in"}
+	}
+	if config.Contexts == nil {
+		config.Contexts = make(map[string]map[string]string)
+	}
+	if config.Contexts[name] == nil {
+		config.Contexts[name] = make(map[string]string)
+		log.Infof("Created the context %s", name)
+	}
+	config.Contexts[name][key] = value
+	if len(config.CurrentContext) == 0 {
+		config.CurrentContext = name
+	}
+	if err = config.save(); err != nil {
+		return err
+	}
+	log.Infof("Set %s in the context %s", key, name)
+	return nil
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"github.com/urfave/cli/v2"
 )
 
-func migrateConnectors(*cli.Context) (err error) {
-	promptConfirm := PromptConnectorDetails()
-	promptConfirm = PromptOrgAndProject([]string{migrationReq.ConnectorScope, migrationReq.SecretScope}) || promptConfirm
+func migrateConnectors(ctx *cli.Context) (err error) {
+	promptConfirm, err := PromptConnectorDetails()
+	if err != nil {
+		return err
+	}
+	promptConfirm, err = PromptOrgAndProject([]string{migrationReq.ConnectorScope, migrationReq.SecretScope}, promptConfirm)
+	if err != nil {
+		return err
+	}
 
-	err = MigrateEntities(promptConfirm, []string{migrationReq.ConnectorScope, migrationReq.SecretScope}, "connectors", Connector)
+	err = MigrateEntities(ctx.Context, promptConfirm, []string{migrationReq.ConnectorScope, migrationReq.SecretScope}, "connectors", Connector)
 	if err != nil {
-		log.Fatal("Failed to migrate connectors")
+		return fmt.Errorf("failed to migrate connectors: %w", err)
 	}
 	return
 }
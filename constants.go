@@ -29,6 +29,12 @@ const (
 
 var scopes = []string{"project", "org", "account"}
 
+// Formats of the identifiers generated from names
+const (
+	CamelCaseFormat = "CAMEL_CASE"
+	LowerCaseFormat = "LOWER_CASE"
+)
+
 const (
 	AccountIdentifier = "accountIdentifier"
 	OrgIdentifier     = "orgIdentifier"
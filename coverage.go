@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jszwec/csvutil"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+)
+
+var expressionsReportFormats = []string{JsonReport, CsvReport}
+
+// ExpressionOccurrence is an expression found in a file along with its Next Gen equivalent
+type ExpressionOccurrence struct {
+	File      string `json:"file" csv:"file"`
+	Line      int    `json:"line" csv:"line"`
+	Column    int    `json:"column" csv:"column"`
+	Original  string `json:"original" csv:"original"`
+	Proposed  string `json:"proposed" csv:"proposed"`
+	Supported bool   `json:"supported" csv:"supported"`
+}
+
+// ExpressionCoverage is the share of the expressions that can be converted automatically
+type ExpressionCoverage struct {
+	Files             int     `json:"files"`
+	Occurrences       int     `json:"occurrences"`
+	Supported         int     `json:"supported"`
+	Distinct          int     `json:"distinct"`
+	DistinctSupported int     `json:"distinctSupported"`
+	Percent           float64 `json:"percent"`
+}
+
+type ExpressionsReport struct {
+	Coverage    ExpressionCoverage     `json:"coverage"`
+	Occurrences []ExpressionOccurrence `json:"occurrences"`
+}
+
+// ReportExpressions writes every occurrence of an expression in the files & prints how many of them can be
+// converted automatically
+func ReportExpressions(ctx *cli.Context) error {
+	err := assertAllowedValues(migrationReq.ExpressionsReportFormat, expressionsReportFormats, fmt.Sprintf("Invalid report format %s. Possible values - %s", migrationReq.ExpressionsReportFormat, strings.Join(expressionsReportFormats, ", ")))
+	if err != nil {
+		return err
+	}
+	if slices.Contains(migrationReq.Paths.Value(), "-") {
+		return &ValidationError{Message: "Reading from stdin is not supported by the report"}
+	}
+	err = loadExpressionSettings(ctx.Context)
+	if err != nil {
+		return err
+	}
+	files, err := findExpressionFiles()
+	if err != nil {
+		return err
+	}
+
+	var occurrences []ExpressionOccurrence
+	for _, path := range files {
+		content, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+		occurrences = append(occurrences, getExpressionOccurrences(path, content)...)
+	}
+	report := ExpressionsReport{Coverage: getExpressionCoverage(occurrences), Occurrences: occurrences}
+
+	var content []byte
+	if migrationReq.ExpressionsReportFormat == CsvReport {
+		content, err = csvutil.Marshal(occurrences)
+	} else {
+		// Expressions are easier to read without escaping < & >
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+		content = buf.Bytes()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create the report: %w", err)
+	}
+	if len(migrationReq.ExpressionsReportFile) > 0 {
+		err = WriteFileAtomic(migrationReq.ExpressionsReportFile, content)
+		if err == nil {
+			log.Infof("The expressions report was written to %s", migrationReq.ExpressionsReportFile)
+		}
+	} else {
+		_, err = os.Stdout.Write(content)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the report: %w", err)
+	}
+
+	c := report.Coverage
+	log.Infof("Found %d expressions (%d distinct) in %d files", c.Occurrences, c.Distinct, c.Files)
+	log.Infof("Coverage: %d of %d expressions (%.1f%%) & %d of %d distinct expressions can be converted automatically", c.Supported, c.Occurrences, c.Percent, c.DistinctSupported, c.Distinct)
+	return nil
+}
+
+// getExpressionOccurrences returns the top level expressions of the file with their Next Gen equivalent in the scope
+// they are found in
+func getExpressionOccurrences(path string, content string) []ExpressionOccurrence {
+	var occurrences []ExpressionOccurrence
+	doc := parseYamlDocument(content)
+	for _, e := range ParseExpressions(content) {
+		proposed, ok := ConvertExpression(e, doc.scopeOf(path, e))
+		occurrences = append(occurrences, ExpressionOccurrence{
+			File:      path,
+			Line:      e.Start.Line,
+			Column:    e.Start.Column,
+			Original:  e.Raw,
+			Proposed:  proposed,
+			Supported: ok,
+		})
+	}
+	return occurrences
+}
+
+func getExpressionCoverage(occurrences []ExpressionOccurrence) ExpressionCoverage {
+	c := ExpressionCoverage{Occurrences: len(occurrences), Percent: 100}
+	files := make(map[string]bool)
+	distinct := make(map[string]bool)
+	for _, o := range occurrences {
+		files[o.File] = true
+		// The same expression can be supported in one scope but not in another
+		distinct[o.Original] = distinct[o.Original] || o.Supported
+		if o.Supported {
+			c.Supported++
+		}
+	}
+	c.Files = len(files)
+	c.Distinct = len(distinct)
+	for _, supported := range distinct {
+		if supported {
+			c.DistinctSupported++
+		}
+	}
+	if c.Occurrences > 0 {
+		c.Percent = float64(c.Supported) * 100 / float64(c.Occurrences)
+	}
+	return c
+}
+
+// logToStderrForReport writes the logs to stderr when the report is written to stdout
+func logToStderrForReport(*cli.Context) error {
+	if len(migrationReq.ExpressionsReportFile) == 0 {
+		log.SetOutput(os.Stderr)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+)
+
+// Formats in which the dependency graph can be printed
+const (
+	TextGraph = "text"
+	DotGraph  = "dot"
+)
+
+// entityDependencies lists the entity types that an entity type can reference
+var entityDependencies = map[EntityType][]EntityType{
+	Secret:              {SecretManager},
+	Connector:           {Secret},
+	Template:            {Secret, Connector},
+	Service:             {Secret, Connector, Template},
+	ApplicationManifest: {Connector, Service},
+	Environment:         {Secret, Connector},
+	Infrastructure:      {Connector, Environment},
+	Workflow:            {Template, Service, Environment, Infrastructure},
+	Pipeline:            {Workflow},
+	Trigger:             {Workflow, Pipeline},
+}
+
+// dependencyOrder is the order in which the entity types are migrated so that every entity is migrated after the
+// entities it depends on
+var dependencyOrder = []EntityType{SecretManager, Secret, Connector, Template, Service, ApplicationManifest, Environment, Infrastructure, Workflow, Pipeline, Trigger}
+
+// appImports are the imports that migrate the entity types of an app. Account level entity types are migrated by the
+// server along with the entities that reference them.
+var appImports = map[EntityType]EntityType{
+	Service:             Application,
+	ApplicationManifest: Application,
+	Environment:         Application,
+	Infrastructure:      Application,
+	Workflow:            Workflow,
+	Pipeline:            Pipeline,
+}
+
+var importLabels = map[EntityType]string{
+	Application: "services, environments, infrastructure & manifests",
+	Workflow:    "workflows",
+	Pipeline:    "pipelines",
+	Trigger:     "triggers",
+}
+
+type DependencyNode struct {
+	Type      EntityType
+	Count     int64
+	DependsOn []EntityType
+}
+
+// DependencyGraph holds the entity types that are present in an app in the order they are migrated
+type DependencyGraph struct {
+	AppId string
+	Nodes []DependencyNode
+}
+
+func PrintDependencies(ctx *cli.Context) error {
+	_, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	if len(migrationReq.AppId) == 0 {
+		migrationReq.AppId, err = TextInput("Please provide the application ID - ")
+		if err != nil {
+			return err
+		}
+	}
+	format := getOrDefault(migrationReq.DepsFormat, TextGraph)
+	if err := assertAllowedValues(format, []string{TextGraph, DotGraph}, fmt.Sprintf("Invalid format %s. Possible values - %s, %s", format, TextGraph, DotGraph)); err != nil {
+		return err
+	}
+	summary, err := getAppSummary(ctx.Context, migrationReq.AppId)
+	if err != nil {
+		return err
+	}
+	graph := buildDependencyGraph(migrationReq.AppId, summary)
+	if format == DotGraph {
+		fmt.Print(graph.toDot())
+	} else {
+		fmt.Print(graph.toText())
+	}
+	return nil
+}
+
+// getDependencyImports returns the imports of the app that have to run before the given entity type is imported. As
+// they import the whole app, they only run for runs scoped to specific entities with --with-deps.
+func getDependencyImports(ctx context.Context, entityType EntityType, scoped bool) ([]EntityType, error) {
+	if migrationReq.NoDeps {
+		return nil, nil
+	}
+	if scoped && !migrationReq.WithDeps {
+		log.Infof("Skipping the import of what the %s depend on as only some of them are imported. Pass --with-deps to import it first", importLabels[entityType])
+		return nil, nil
+	}
+	log.Info("Building the dependency graph of the app....")
+	summary, err := getAppSummary(ctx, migrationReq.AppId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the dependency graph of the app: %w", err)
+	}
+	imports := buildDependencyGraph(migrationReq.AppId, summary).getPrerequisiteImports(entityType)
+	for _, prerequisite := range imports {
+		log.Warnf("All the %s of the app will be imported first as the %s depend on them. Pass --no-deps to skip this", importLabels[prerequisite], importLabels[entityType])
+	}
+	return imports, nil
+}
+
+// migrateDependencies runs the imports of the app that the entity type depends on
+func migrateDependencies(ctx context.Context, imports []EntityType) error {
+	for _, prerequisite := range imports {
+		log.Infof("Importing the %s of the app....", importLabels[prerequisite])
+		if err := createEntities(ctx, prerequisite, Filter{AppId: migrationReq.AppId}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getAppSummary(ctx context.Context, appId string) (map[string]EntitySummary, error) {
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "discover/summary/async", map[string]string{
+		AccountIdentifier: migrationReq.Account,
+		"appId":           appId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reqId, err := queueSummary(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := waitForSummary(ctx, reqId)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := getSummary(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account summary: %w", err)
+	}
+	return summary.Summary, nil
+}
+
+func buildDependencyGraph(appId string, summary map[string]EntitySummary) DependencyGraph {
+	present := func(entityType EntityType) bool {
+		return summary[string(entityType)].Count > 0
+	}
+	graph := DependencyGraph{AppId: appId}
+	for _, entityType := range dependencyOrder {
+		if !present(entityType) {
+			continue
+		}
+		graph.Nodes = append(graph.Nodes, DependencyNode{
+			Type:      entityType,
+			Count:     summary[string(entityType)].Count,
+			DependsOn: getNearestDependencies(entityType, present),
+		})
+	}
+	return graph
+}
+
+// getNearestDependencies walks through the entity types that are not present to the ones that are
+func getNearestDependencies(entityType EntityType, present func(EntityType) bool) []EntityType {
+	var result []EntityType
+	for _, dependency := range entityDependencies[entityType] {
+		if present(dependency) {
+			if !slices.Contains(result, dependency) {
+				result = append(result, dependency)
+			}
+			continue
+		}
+		for _, d := range getNearestDependencies(dependency, present) {
+			if !slices.Contains(result, d) {
+				result = append(result, d)
+			}
+		}
+	}
+	return result
+}
+
+// getPrerequisiteImports returns the imports of the app that have to run before the given entity type is imported
+func (g DependencyGraph) getPrerequisiteImports(entityType EntityType) []EntityType {
+	ancestors := make(map[EntityType]bool)
+	var visit func(t EntityType)
+	visit = func(t EntityType) {
+		for _, dependency := range entityDependencies[t] {
+			if !ancestors[dependency] {
+				ancestors[dependency] = true
+				visit(dependency)
+			}
+		}
+	}
+	visit(entityType)
+
+	var imports []EntityType
+	for _, node := range g.Nodes {
+		prerequisite, ok := appImports[node.Type]
+		if !ancestors[node.Type] || !ok || prerequisite == entityType || slices.Contains(imports, prerequisite) {
+			continue
+		}
+		imports = append(imports, prerequisite)
+	}
+	return imports
+}
+
+func (g DependencyGraph) toText() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Dependencies of the app %s in the order they are migrated\n", g.AppId))
+	for i, node := range g.Nodes {
+		sb.WriteString(fmt.Sprintf("%2d. %s (%d)", i+1, node.Type, node.Count))
+		if len(node.DependsOn) > 0 {
+			var dependencies []string
+			for _, d := range node.DependsOn {
+				dependencies = append(dependencies, string(d))
+			}
+			sb.WriteString(" depends on " + strings.Join(dependencies, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (g DependencyGraph) toDot() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("digraph %q {\n  rankdir=LR;\n", g.AppId))
+	for _, node := range g.Nodes {
+		sb.WriteString(fmt.Sprintf("  %q [label=\"%s (%d)\"];\n", node.Type, node.Type, node.Count))
+	}
+	for _, node := range g.Nodes {
+		for _, d := range node.DependsOn {
+			sb.WriteString(fmt.Sprintf("  %q -> %q;\n", d, node.Type))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
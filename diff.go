@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// diffContext is the number of unchanged lines around the changes of a hunk
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ' for unchanged, '-' for deleted & '+' for inserted lines
+	line string
+}
+
+// UnifiedDiff returns a unified diff of the file in the format of `git diff` that can be applied using `git apply`.
+// No diff is returned if the contents are the same.
+func UnifiedDiff(path string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+	ops := diffLines(splitLines(before), splitLines(after))
+	path = patchPath(path)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path))
+	for _, hunk := range getHunks(ops) {
+		sb.WriteString(hunk)
+	}
+	return sb.String()
+}
+
+// patchPath returns the path of the file in a patch. It is relative to the root of the git repository of the file or
+// else to the working directory, so that the patch can be applied using `git apply` or `patch -p1` whatever the --path.
+func patchPath(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return filepath.ToSlash(filepath.Clean(p))
+	}
+	base, ok := findGitRoot(filepath.Dir(abs))
+	if !ok {
+		if base, err = os.Getwd(); err != nil {
+			return filepath.ToSlash(filepath.Clean(p))
+		}
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(filepath.Clean(p))
+	}
+	return filepath.ToSlash(rel)
+}
+
+// splitLines splits the content into lines that keep their line endings
+func splitLines(content string) []string {
+	var lines []string
+	for len(content) > 0 {
+		i := strings.IndexByte(content, '\n')
+		if i < 0 {
+			lines = append(lines, content)
+			break
+		}
+		lines = append(lines, content[:i+1])
+		content = content[i+1:]
+	}
+	return lines
+}
+
+// diffLines returns the operations to turn a into b. Replacing expressions does not add or remove lines, so files
+// with the same number of lines are compared line by line. Others are compared using the Myers algorithm.
+func diffLines(a []string, b []string) []diffOp {
+	var ops []diffOp
+	if len(a) == len(b) {
+		for i := range a {
+			if a[i] == b[i] {
+				ops = append(ops, diffOp{' ', a[i]})
+				continue
+			}
+			ops = append(ops, diffOp{'-', a[i]}, diffOp{'+', b[i]})
+		}
+		return ops
+	}
+
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, max)
+			}
+		}
+	}
+	return ops
+}
+
+func backtrack(trace [][]int, a []string, b []string, max int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[max+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{'+', b[y-1]})
+				y--
+			} else {
+				ops = append(ops, diffOp{'-', a[x-1]})
+				x--
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// getHunks groups the changes that are close to each other along with the unchanged lines around them
+func getHunks(ops []diffOp) []string {
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	var hunks []string
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext {
+			j++
+		}
+		start := changes[i] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+		hunks = append(hunks, formatHunk(ops, start, end))
+		i = j + 1
+	}
+	return hunks
+}
+
+func formatHunk(ops []diffOp, start int, end int) string {
+	var aBefore, bBefore, aLen, bLen int
+	for i, op := range ops[:end] {
+		inHunk := i >= start
+		if op.kind != '+' {
+			if inHunk {
+				aLen++
+			} else {
+				aBefore++
+			}
+		}
+		if op.kind != '-' {
+			if inHunk {
+				bLen++
+			} else {
+				bBefore++
+			}
+		}
+	}
+	aStart, bStart := aBefore+1, bBefore+1
+	if aLen == 0 {
+		aStart = aBefore
+	}
+	if bLen == 0 {
+		bStart = bBefore
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen))
+	for _, op := range ops[start:end] {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	return sb.String()
+}
+
+// printColoredDiff prints the diff with deleted lines in red & inserted lines in green. Colors are disabled when the
+// output is not a terminal.
+func printColoredDiff(diff string) {
+	header := color.New(color.Bold)
+	hunk := color.New(color.FgCyan)
+	deleted := color.New(color.FgRed)
+	inserted := color.New(color.FgGreen)
+	inHeader := false
+	for _, line := range splitLines(diff) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git"):
+			inHeader = true
+			header.Println(line)
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			hunk.Println(line)
+		case inHeader:
+			header.Println(line)
+		case strings.HasPrefix(line, "-"):
+			deleted.Println(line)
+		case strings.HasPrefix(line, "+"):
+			inserted.Println(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	got := UnifiedDiff("pipelines/a.yaml", "a: 1\nb: ${x}\nc: 3\n", "a: 1\nb: <+x>\nc: 3\n")
+	want := "diff --git a/pipelines/a.yaml b/pipelines/a.yaml\n--- a/pipelines/a.yaml\n+++ b/pipelines/a.yaml\n" +
+		"@@ -1,3 +1,3 @@\n a: 1\n-b: ${x}\n+b: <+x>\n c: 3\n"
+	if got != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := UnifiedDiff("a.yaml", "a: 1\n", "a: 1\n"); got != "" {
+		t.Errorf("UnifiedDiff() of the same contents = %q, want no diff", got)
+	}
+}
+
+func TestUnifiedDiffPaths(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+
+	// A git repository with the working directory in a subdirectory of it
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".git", "pipelines", "services"} {
+		if err = os.Mkdir(filepath.Join(repo, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory outside of any git repository
+	plain, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := findGitRoot(plain); ok {
+		t.Skip("the temporary directory is in a git repository")
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		path string
+		want string
+	}{
+		{name: "absolute path in a repository", dir: filepath.Join(repo, "pipelines"), path: filepath.Join(repo, "services", "a.yaml"), want: "services/a.yaml"},
+		{name: "parent path in a repository", dir: filepath.Join(repo, "pipelines"), path: filepath.Join("..", "services", "a.yaml"), want: "services/a.yaml"},
+		{name: "relative path in a repository", dir: filepath.Join(repo, "pipelines"), path: "a.yaml", want: "pipelines/a.yaml"},
+		{name: "absolute path outside of a repository", dir: plain, path: filepath.Join(plain, "services", "a.yaml"), want: "services/a.yaml"},
+		{name: "dotted path outside of a repository", dir: plain, path: "./services/../pipelines/a.yaml", want: "pipelines/a.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(tt.dir); err != nil {
+				t.Fatal(err)
+			}
+			got := UnifiedDiff(tt.path, "a: ${x}\n", "a: <+x>\n")
+			header := "diff --git a/" + tt.want + " b/" + tt.want + "\n--- a/" + tt.want + "\n+++ b/" + tt.want + "\n"
+			if !strings.HasPrefix(got, header) {
+				t.Errorf("UnifiedDiff(%q) =\n%s\nwant the header\n%s", tt.path, got, header)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/briandowns/spinner"
 	"github.com/jedib0t/go-pretty/v6/table"
 	log "github.com/sirupsen/logrus"
@@ -10,6 +13,12 @@ import (
 	"time"
 )
 
+// Kinds of async requests that can be polled for their results
+const (
+	SaveRequest    = "save"
+	SummaryRequest = "summary"
+)
+
 var skipLogs = []string{
 	"already exists in the parent folder",
 	"Duplicate identifier, please try again with a new identifier",
@@ -20,66 +29,175 @@ var skipLogs = []string{
 	"] already exists",
 }
 
-func CreateEntities(body RequestBody) {
-	reqId, err := QueueCreateEntity(body)
+func CreateEntities(ctx context.Context, body RequestBody) error {
+	return runSaveStep(ctx, body, PollForCompletion)
+}
+
+// runSaveStep queues the migration request of a step unless a previous run already did & waits for it using poll
+func runSaveStep(ctx context.Context, body RequestBody, poll func(ctx context.Context, reqId string) error) error {
+	key := getStepKey(body)
+	if step, ok := getStep(key); ok {
+		switch step.Status {
+		case StepDone:
+			log.Infof("Skipping the %s migration as it was completed in a previous run with request id - %s", body.EntityType, step.RequestId)
+			// The report of the run includes what the previous run migrated
+			if step.SaveSummary != nil {
+				recordSaveSummary(step.RequestId, *step.SaveSummary)
+			}
+			return nil
+		case StepQueued:
+			log.Infof("Re-attaching to the %s migration from a previous run with request id - %s", body.EntityType, step.RequestId)
+			return pollStep(ctx, key, step.RequestId, poll)
+		}
+	}
+	reqId, err := QueueCreateEntity(ctx, body)
 	if err != nil {
-		return
+		return err
 	}
-	PollForCompletion(reqId)
+	updateStep(key, func(step *StepState) {
+		step.EntityType = body.EntityType
+		step.AppId = body.Filter.AppId
+		step.RequestId = reqId
+		step.Status = StepQueued
+		step.SaveSummary = nil
+	})
+	return pollStep(ctx, key, reqId, poll)
 }
 
-func QueueCreateEntity(body RequestBody) (reqId string, err error) {
-	url := GetUrl(migrationReq.Environment, MigratorService, "save/async", migrationReq.Account)
-	resp, err := Post(url, migrationReq.Auth, body)
+// pollStep waits for the request of a step. A step that fails on the server is queued afresh when the run is resumed,
+// while a step that was interrupted is re-attached to.
+func pollStep(ctx context.Context, key string, reqId string, poll func(ctx context.Context, reqId string) error) error {
+	err := poll(ctx, reqId)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		updateStep(key, func(step *StepState) {
+			step.Status = StepFailed
+		})
+	}
+	return err
+}
+
+func QueueCreateEntity(ctx context.Context, body RequestBody) (reqId string, err error) {
+	url, err := GetUrl(migrationReq.Environment, MigratorService, "save/async", migrationReq.Account)
 	if err != nil {
-		log.Fatal("Failed to create the entities", err)
+		return "", err
+	}
+	resp, err := Post(ctx, url, migrationReq.Auth, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the entities: %w", err)
 	}
 	resource, err := getResource(resp.Resource)
-	if err != nil || len(resource.RequestId) == 0 {
-		log.Fatal("Failed to create the entities", err)
-		return
+	if err != nil {
+		return "", fmt.Errorf("failed to create the entities: %w", err)
+	}
+	if len(resource.RequestId) == 0 {
+		return "", &APIError{StatusCode: 200, Url: url, Message: "no request id was returned for the migration request"}
 	}
 	reqId = resource.RequestId
 	log.Infof("The request id is - %s", reqId)
 	return
 }
 
-func PollForCompletion(reqId string) {
+func PollForCompletion(ctx context.Context, reqId string) error {
 	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 	s.Suffix = " Processing"
 	s.Start()
+	defer s.Stop()
 	for {
-		time.Sleep(time.Second * 10)
-		url := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "save/async-result", map[string]string{
-			AccountIdentifier: migrationReq.Account,
-			"requestId":       reqId,
-		})
-		resp, err := Get(url, migrationReq.Auth)
-		if err != nil {
+		if err := sleepWithContext(ctx, time.Second*10); err != nil {
 			s.Stop()
-			log.Fatal("Failed to create the entities", err)
+			return interrupted(reqId, SaveRequest, err)
 		}
-		resource, err := getResource(resp.Resource)
+		resource, done, err := getSaveResult(ctx, reqId)
 		if err != nil {
 			s.Stop()
-			log.Fatal("Failed to create the entities", err)
-		}
-		if resource.Status == "ERROR" {
-			s.Stop()
-			log.Fatal("Failed to create the entities")
+			if ctx.Err() != nil {
+				return interrupted(reqId, SaveRequest, ctx.Err())
+			}
+			return err
 		}
-		if resource.Status == "DONE" {
+		if done {
 			s.Stop()
-			saveSummary, err := getSaveSummary(resource)
-			if err != nil {
-				log.Fatal("Failed to create the entities", err)
-			}
-			renderSaveSummary(saveSummary)
-			break
+			return renderSaveResult(reqId, resource)
 		}
 	}
 }
 
+// getSaveResult fetches the current result of a save request & reports if it has completed
+func getSaveResult(ctx context.Context, reqId string) (resource Resource, done bool, err error) {
+	resource, err = getAsyncResult(ctx, "save/async-result", reqId)
+	if err != nil {
+		return resource, false, fmt.Errorf("failed to fetch the result of the request %s: %w", reqId, err)
+	}
+	return resource, resource.Status == "DONE", nil
+}
+
+func renderSaveResult(reqId string, resource Resource) error {
+	saveSummary, err := completeSaveRequest(reqId, resource)
+	if err != nil {
+		return err
+	}
+	renderSaveSummary(saveSummary)
+	return nil
+}
+
+// completeSaveRequest records the summary of a completed save request in the report & the state of the run
+func completeSaveRequest(reqId string, resource Resource) (SaveSummary, error) {
+	saveSummary, err := getSaveSummary(resource)
+	if err != nil {
+		return saveSummary, fmt.Errorf("failed to fetch the result of the request %s: %w", reqId, err)
+	}
+	recordSaveSummary(reqId, saveSummary)
+	markRequestDone(reqId, saveSummary)
+	return saveSummary, nil
+}
+
+// getAsyncResult fetches the result of an async request from the given endpoint. A request that failed on the
+// server is reported as an APIError.
+func getAsyncResult(ctx context.Context, endpoint string, reqId string) (resource Resource, err error) {
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, endpoint, map[string]string{
+		AccountIdentifier: migrationReq.Account,
+		"requestId":       reqId,
+	})
+	if err != nil {
+		return
+	}
+	resp, err := Get(ctx, url, migrationReq.Auth)
+	if err != nil {
+		return
+	}
+	resource, err = getResource(resp.Resource)
+	if err != nil {
+		return
+	}
+	if resource.Status == "ERROR" {
+		return resource, &APIError{StatusCode: 200, Url: url, Message: fmt.Sprintf("the request %s failed", reqId)}
+	}
+	return
+}
+
+// interrupted logs the details required to resume polling a request that is still being processed on the server
+func interrupted(reqId string, kind string, err error) error {
+	log.WithFields(log.Fields{
+		"requestId": reqId,
+		"kind":      kind,
+	}).Warn("Stopped waiting for the request. It is still being processed by Harness")
+	log.Warnf("To resume polling run - %s", resumeCommand(reqId, kind))
+	if kind == SaveRequest {
+		log.Warn("To continue with the remaining steps of this run use - harness-upgrade resume")
+	}
+	return &InterruptedError{RequestId: reqId, Kind: kind, Err: err}
+}
+
+func resumeCommand(reqId string, kind string) string {
+	args := []string{"harness-upgrade", "--env", migrationReq.Environment}
+	if migrationReq.Environment == SelfManaged {
+		args = append(args, "--base-url", migrationReq.BaseUrl)
+	}
+	args = append(args, "--account", migrationReq.Account, "status", "--request-id", reqId, "--kind", kind, "--wait")
+	return strings.Join(args, " ")
+}
+
 func getSaveSummary(resource Resource) (summary SaveSummary, err error) {
 	byteData, err := json.Marshal(resource.ResponsePayload)
 	if err != nil {
@@ -124,11 +242,8 @@ func renderSaveSummary(saveSummary SaveSummary) {
 			e := saveSummary.Errors[i]
 			level := log.ErrorLevel
 			// log as debug if the error is in skipLogs
-			for _, v := range skipLogs {
-				if strings.Contains(e.Message, v) {
-					level = log.DebugLevel
-					break
-				}
+			if isSkippableError(e.Message) {
+				level = log.DebugLevel
 			}
 			logWithDetails(level, e.Entity, e.Message)
 		}
@@ -152,6 +267,15 @@ func renderSaveSummary(saveSummary SaveSummary) {
 	}
 }
 
+func isSkippableError(message string) bool {
+	for _, v := range skipLogs {
+		if strings.Contains(message, v) {
+			return true
+		}
+	}
+	return false
+}
+
 func logWithDetails(level log.Level, entity CurrentGenEntity, message string) {
 	if len(entity.Id) > 0 {
 		log.WithFields(log.Fields{
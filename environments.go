@@ -1,20 +1,26 @@
 package main
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"github.com/urfave/cli/v2"
 )
 
-func migrateEnvironments(*cli.Context) (err error) {
-	promptConfirm := PromptDefaultInputs()
+func migrateEnvironments(ctx *cli.Context) (err error) {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
 		promptConfirm = true
-		migrationReq.AppId = TextInput("Please provide the application ID -")
+		migrationReq.AppId, err = TextInput("Please provide the application ID -")
+		if err != nil {
+			return err
+		}
 	}
 
-	err = MigrateEntities(promptConfirm, []string{Project}, "environments", Environment)
+	err = MigrateEntities(ctx.Context, promptConfirm, []string{Project}, "environments", Environment)
 	if err != nil {
-		log.Fatal("Failed to migrate environments")
+		return fmt.Errorf("failed to migrate environments: %w", err)
 	}
 	return
 }
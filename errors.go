@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Exit codes of the CLI. These are documented & CI jobs rely on them to branch on the class of failure.
+const (
+	ExitCodeSuccess     = 0
+	ExitCodeFailure     = 1
+	ExitCodeValidation  = 2
+	ExitCodeAPI         = 3
+	ExitCodeAborted     = 4
+	ExitCodeInterrupted = 5
+)
+
+// APIError is returned when a Harness API responds with a failure
+type APIError struct {
+	StatusCode int
+	Url        string
+	Message    string
+	Messages   []ResponseMessages
+}
+
+func (e *APIError) Error() string {
+	var details []string
+	if len(e.Message) > 0 {
+		details = append(details, e.Message)
+	}
+	for _, m := range e.Messages {
+		if len(m.Message) > 0 && m.Message != e.Message {
+			details = append(details, m.Message)
+		}
+	}
+	msg := fmt.Sprintf("request to %s failed with status code %d", stripQuery(e.Url), e.StatusCode)
+	if len(details) > 0 {
+		msg = msg + " - " + strings.Join(details, "; ")
+	}
+	return msg
+}
+
+// ValidationError is returned when the inputs provided to the CLI are invalid
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// AbortedByUser is returned when the user declines to proceed at a confirmation prompt
+type AbortedByUser struct{}
+
+func (e *AbortedByUser) Error() string {
+	return "aborted by user"
+}
+
+// InterruptedError is returned when the CLI is interrupted or times out while an async request is still being processed
+type InterruptedError struct {
+	RequestId string
+	Kind      string
+	Err       error
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("stopped waiting for the request %s - %s", e.RequestId, e.Err)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+func exitCodeFor(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var apiErr *APIError
+	var validationErr *ValidationError
+	var abortedErr *AbortedByUser
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return ExitCodeInterrupted
+	case errors.As(err, &abortedErr):
+		return ExitCodeAborted
+	case errors.As(err, &validationErr):
+		return ExitCodeValidation
+	case errors.As(err, &apiErr):
+		return ExitCodeAPI
+	default:
+		return ExitCodeFailure
+	}
+}
+
+func stripQuery(url string) string {
+	if i := strings.Index(url, "?"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
@@ -1,19 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+	"io"
 	"os"
-	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 )
 
-const ExpressionPattern = "\\$\\{[\\w-.\"()]+}"
-const SecretExpressionPattern = "\\$\\{secrets.getValue\\([^{}]+\\)}"
-
 var ExpressionsMap = map[string]string{
 	"deploymentTriggeredBy": "<+pipeline.triggeredBy.name>",
 	"currentStep.name":      "<+step.name>",
@@ -75,20 +75,34 @@ var ExpressionsMap = map[string]string{
 	"artifact.source.repositoryName":     "<+artifact.imagePath>",
 
 	// Rollback Artifact Expressions
-	"rollbackArtifact.metadata.image":          "<+rollbackArtifact.image>",
-	"rollbackArtifact.metadata.tag":            "<+rollbackArtifact.tag>",
-	"rollbackArtifact.source.dockerconfig":     "<+rollbackArtifact.imagePullSecret>",
-	"rollbackArtifact.metadata.fileName":       "<+rollbackArtifact.fileName>",
-	"rollbackArtifact.metadata.format":         "<+rollbackArtifact.repositoryFormat>",
-	"rollbackArtifact.metadata.getSHA()":       "<+rollbackArtifact.metadata.SHA>",
-	"rollbackArtifact.metadata.groupId":        "<+rollbackArtifact.groupId>",
-	"rollbackArtifact.metadata.package":        "<+rollbackArtifact.metadata.package>",
-	"rollbackArtifact.metadata.region":         "<+rollbackArtifact.metadata.region>",
-	"rollbackArtifact.metadata.repository":     "<+rollbackArtifact.repository>",
-	"rollbackArtifact.metadata.repositoryName": "<+rollbackArtifact.repositoryName>",
-	"rollbackArtifact.metadata.url":            "<+rollbackArtifact.url>",
-	"rollbackArtifact.buildNo":                 "<+rollbackArtifact.tag>",
-	"rollbackArtifact.source.repositoryName":   "<+rollbackArtifact.imagePath>",
+	"rollbackArtifact.metadata.image":            "<+rollbackArtifact.image>",
+	"rollbackArtifact.metadata.tag":              "<+rollbackArtifact.tag>",
+	"rollbackArtifact.source.dockerconfig":       "<+rollbackArtifact.imagePullSecret>",
+	"rollbackArtifact.metadata.fileName":         "<+rollbackArtifact.fileName>",
+	"rollbackArtifact.metadata.format":           "<+rollbackArtifact.repositoryFormat>",
+	"rollbackArtifact.metadata.getSHA()":         "<+rollbackArtifact.metadata.SHA>",
+	"rollbackArtifact.metadata.groupId":          "<+rollbackArtifact.groupId>",
+	"rollbackArtifact.metadata.package":          "<+rollbackArtifact.metadata.package>",
+	"rollbackArtifact.metadata.region":           "<+rollbackArtifact.metadata.region>",
+	"rollbackArtifact.metadata.repository":       "<+rollbackArtifact.repository>",
+	"rollbackArtifact.metadata.repositoryName":   "<+rollbackArtifact.repositoryName>",
+	"rollbackArtifact.metadata.url":              "<+rollbackArtifact.url>",
+	"rollbackArtifact.buildNo":                   "<+rollbackArtifact.tag>",
+	"rollbackArtifact.source.repositoryName":     "<+rollbackArtifact.imagePath>",
+	"rollbackArtifact.metadata.URL":              "<+rollbackArtifact.url>",
+	"rollbackArtifact.metadata.artifactFileName": "<+rollbackArtifact.metadata.fileName>",
+	"rollbackArtifact.metadata.artifactId":       "<+rollbackArtifact.metadata.artifactId>",
+	"rollbackArtifact.metadata.version":          "<+rollbackArtifact.metadata.version>",
+	"rollbackArtifact.buildFullDisplayName":      "<+rollbackArtifact.uiDisplayName>",
+	"rollbackArtifact.displayName":               "<+rollbackArtifact.displayName>",
+	"rollbackArtifact.revision":                  "<+rollbackArtifact.tag>",
+	"rollbackArtifact.source.registryUrl":        "<+rollbackArtifact.registryUrl>",
+	"rollbackArtifact.URL":                       "<+rollbackArtifact.url>",
+	"rollbackArtifact.url":                       "<+rollbackArtifact.url>",
+	"rollbackArtifact.artifactPath":              "<+rollbackArtifact.artifactPath>",
+	"rollbackArtifact.fileName":                  "<+rollbackArtifact.metadata.fileName>",
+	"rollbackArtifact.key":                       "<+rollbackArtifact.metadata.key>",
+	"rollbackArtifact.bucketName":                "<+rollbackArtifact.metadata.bucketName>",
 
 	// Application Expressions
 	"app.name":                        "<+project.name>",
@@ -126,6 +140,42 @@ var ExpressionsMap = map[string]string{
 	"infra.pcf.space":               "<+infra.space>",
 	"host.pcfElement.applicationId": "<+pcf.newAppGuid>",
 	"host.pcfElement.displayName":   "<+pcf.newAppName>",
+
+	// Triggered By
+	"deploymentTriggeredBy.name":  "<+pipeline.triggeredBy.name>",
+	"deploymentTriggeredBy.email": "<+pipeline.triggeredBy.email>",
+
+	// Instance Expressions
+	"instance.name":          "<+instance.name>",
+	"instance.hostName":      "<+instance.hostName>",
+	"instance.host.hostName": "<+instance.host.hostName>",
+	"instance.host.ip":       "<+instance.host.privateIp>",
+
+	// Host Expressions
+	"host.name":                         "<+instance.name>",
+	"host.hostName":                     "<+instance.hostName>",
+	"host.ip":                           "<+instance.host.privateIp>",
+	"host.ec2Instance.instanceId":       "<+instance.host.instanceId>",
+	"host.ec2Instance.privateIpAddress": "<+instance.host.privateIp>",
+	"host.ec2Instance.publicIpAddress":  "<+instance.host.publicIp>",
+	"host.ec2Instance.privateDnsName":   "<+instance.hostName>",
+
+	// ECS
+	"ecs.clusterName": "<+infra.cluster>",
+	"ecs.region":      "<+infra.region>",
+
+	// K8s
+	"k8s.releaseName": "<+infra.releaseName>",
+
+	// Helm Chart
+	"helmChart.name":                    "<+manifest.chartName>",
+	"helmChart.displayName":             "<+manifest.chartName>",
+	"helmChart.version":                 "<+manifest.chartVersion>",
+	"helmChart.url":                     "<+manifest.repoUrl>",
+	"helmChart.metadata.url":            "<+manifest.repoUrl>",
+	"helmChart.metadata.repositoryName": "<+manifest.repoName>",
+	"helmChart.metadata.bucketName":     "<+manifest.bucketName>",
+	"helmChart.metadata.basePath":       "<+manifest.folderPath>",
 }
 
 var DynamicExpressions = map[string]interface{}{
@@ -151,7 +201,7 @@ var DynamicExpressions = map[string]interface{}{
 		return "<+env.variables." + key + ">"
 	},
 	"secrets.getValue(": func(key string) string {
-		return "<+secrets.getValue(\"" + getSecretKeyWithScope(key) + "\")>"
+		return "<+secrets.getValue(\"" + getSecretReference(strings.Trim(key, "\"'")) + "\")>"
 	},
 	"app.defaults": func(key string) string {
 		return "<+variable." + key + ">"
@@ -162,52 +212,118 @@ var DynamicExpressions = map[string]interface{}{
 	"configFile.getAsString(": func(key string) string {
 		return "<+configFile.getAsString(\"" + key + "\")>"
 	},
+	"artifact.metadata": func(key string) string {
+		return "<+artifact.metadata." + key + ">"
+	},
+	"rollbackArtifact.metadata": func(key string) string {
+		return "<+rollbackArtifact.metadata." + key + ">"
+	},
+	"host.properties": func(key string) string {
+		return "<+instance.host.properties." + key + ">"
+	},
+}
+
+// StepOutputExpressions are the expressions that refer to the outputs of a step of the First Gen workflow they are
+// found in, either by their key or by their family, e.g. ${terraform.clusterName}. The step is looked up in the workflow
+// & its Next Gen identifier is the one of its name, so they are not converted if the workflow has no such step.
+var StepOutputExpressions = map[string]func(steps WorkflowSteps, key string) (string, bool){
+	// Output variables published by shell script steps, e.g. ${context.publishedName.var}
+	"context": func(steps WorkflowSteps, key string) (string, bool) {
+		name, variable, found := strings.Cut(key, ".")
+		if !found {
+			return "", false
+		}
+		step, ok := steps.only(func(step WorkflowStep) bool {
+			return step.Type == "SHELL_SCRIPT" && step.OutputName == name
+		})
+		return stepOutput(step, "outputVariables."+variable), ok
+	},
+	"terraform": func(steps WorkflowSteps, key string) (string, bool) {
+		step, ok := steps.ofType("TERRAFORM_PROVISION", "TERRAFORM_APPLY")
+		return stepOutput(step, key), ok
+	},
+	"cloudformation": func(steps WorkflowSteps, key string) (string, bool) {
+		step, ok := steps.ofType("CLOUD_FORMATION_CREATE_STACK")
+		return stepOutput(step, key), ok
+	},
+
+	// Approval Step
+	"approvedBy.name":  stepOutputOf("approvalActivities[0].user.name", "APPROVAL"),
+	"approvedBy.email": stepOutputOf("approvalActivities[0].user.email", "APPROVAL"),
+
+	// AMI
+	"ami.newAsgName": stepOutputOf("stageAsg.autoScalingGroupName", "AWS_AMI_SERVICE_SETUP"),
+	"ami.oldAsgName": stepOutputOf("prodAsg.autoScalingGroupName", "AWS_AMI_SERVICE_SETUP"),
+
+	// ECS
+	"ecs.serviceName": stepOutputOf("serviceName", "ECS_SERVICE_SETUP", "ECS_DAEMON_SERVICE_SETUP",
+		"ECS_BG_SERVICE_SETUP", "ECS_BG_SERVICE_SETUP_ROUTE53"),
+
+	// K8s
+	"k8s.canaryWorkload":     stepOutputOf("canaryWorkload", "K8S_CANARY_DEPLOY"),
+	"k8s.primaryServiceName": stepOutputOf("primaryServiceName", "K8S_BLUE_GREEN_DEPLOY"),
+	"k8s.stageServiceName":   stepOutputOf("stageServiceName", "K8S_BLUE_GREEN_DEPLOY"),
 }
 
-func getSecretKeyWithScope(key string) string {
-	camelCase := ToCamelCase(key)
-	switch migrationReq.SecretScope {
-	case Account:
-		return Account + "." + camelCase
-	case Org:
-		return Org + "." + camelCase
-	default:
-		return camelCase
+// stepOutputOf returns the output of the only step of the types in the workflow
+func stepOutputOf(output string, types ...string) func(steps WorkflowSteps, key string) (string, bool) {
+	return func(steps WorkflowSteps, _ string) (string, bool) {
+		step, ok := steps.ofType(types...)
+		return stepOutput(step, output), ok
 	}
 }
 
-func ReplaceCurrentGenExpressionsWithNextGen(*cli.Context) (err error) {
-	loadYamlFromFile(migrationReq.CustomExpressionsFile)
+func stepOutput(step string, output string) string {
+	return "<+execution.steps." + step + ".output." + output + ">"
+}
 
-	extensions := Split(migrationReq.FileExtensions, ",")
-	for i, ext := range extensions {
-		extensions[i] = "." + ext
+// convertStepOutput converts an expression that refers to the output of a step of the workflow
+func convertStepOutput(key string, steps WorkflowSteps) (string, bool) {
+	if fn, ok := StepOutputExpressions[key]; ok {
+		return fn(steps, "")
+	}
+	family, rest, found := strings.Cut(key, ".")
+	if fn, ok := StepOutputExpressions[family]; ok && found {
+		return fn(steps, rest)
+	}
+	return "", false
+}
+
+func ReplaceCurrentGenExpressionsWithNextGen(ctx *cli.Context) (err error) {
+	err = loadExpressionSettings(ctx.Context)
+	if err != nil {
+		return err
+	}
+
+	paths := migrationReq.Paths.Value()
+	if slices.Contains(paths, "-") {
+		if len(paths) > 1 {
+			return &ValidationError{Message: "Reading from stdin cannot be combined with other paths"}
+		}
+		if migrationReq.Interactive {
+			return &ValidationError{Message: "Reading from stdin cannot be combined with --interactive"}
+		}
+		return replaceExpressionsFromStdin()
+	}
+	files, err := findExpressionFiles()
+	if err != nil {
+		return err
 	}
 
 	foundExpressionsMap := make(map[string][]string)
 	var allExpressions []string
 
 	// Fetch all expressions per file
-	err = filepath.Walk("./", func(path string, info os.FileInfo, err error) error {
+	for _, path := range files {
+		content, err := ReadFile(path)
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && EndsWith(info.Name(), extensions) && info.Mode().Type() != os.ModeSymlink {
-			content, err := ReadFile(path)
-			if err != nil {
-				return err
-			}
-			foundExpressions := Set(FindAllExpressions(content))
-			if len(foundExpressions) > 0 {
-				foundExpressionsMap[path] = foundExpressions
-				allExpressions = Set(append(allExpressions, foundExpressions...))
-			}
+		foundExpressions := Set(FindAllExpressions(content))
+		if len(foundExpressions) > 0 {
+			foundExpressionsMap[path] = foundExpressions
+			allExpressions = Set(append(allExpressions, foundExpressions...))
 		}
-		return nil
-	})
-
-	if err != nil {
-		return err
 	}
 
 	if len(foundExpressionsMap) == 0 {
@@ -223,28 +339,78 @@ func ReplaceCurrentGenExpressionsWithNextGen(*cli.Context) (err error) {
 	renderSupportedExpressionsTable(allExpressions)
 	renderTable("Files containing expressions", data)
 
-	if migrationReq.DryRun {
+	preview := migrationReq.Diff || len(migrationReq.PatchFile) > 0
+	if migrationReq.DryRun && !preview {
 		log.Info("Dry run is set to true. Skipping expressions replacement for all files")
 		return err
 	}
 
-	// We are going to do an actual replacement
+	// We are going to do an actual replacement or preview it
+	paths = make([]string, 0, len(foundExpressionsMap))
+	for path := range foundExpressionsMap {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var review expressionReviewer
+	if migrationReq.Interactive {
+		review, err = newInteractiveReviewer()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The run is only recorded once a file is replaced
+	var manifest *ExpressionsManifest
+
+	var patch strings.Builder
 	notReplacedMap := make(map[string][]string)
-	for path, expList := range foundExpressionsMap {
+	for _, path := range paths {
 		content, err := ReadFile(path)
 		if err != nil {
 			return err
 		}
-		str, notReplaced := ReplaceAllExpressions(content, expList)
+		str, notReplaced, err := replaceExpressions(path, content, review)
+		if err != nil {
+			return err
+		}
 		if len(notReplaced) > 0 {
 			notReplacedMap[path] = notReplaced
 		}
-		err = WriteToFile(path, []byte(str))
+		if preview {
+			diff := UnifiedDiff(path, content, str)
+			if migrationReq.Diff && len(diff) > 0 {
+				printColoredDiff(diff)
+			}
+			patch.WriteString(diff)
+			continue
+		}
+		if str == content {
+			continue
+		}
+		if manifest == nil {
+			manifest, err = startExpressionsRun()
+			if err != nil {
+				return err
+			}
+		}
+		err = manifest.backup(path, content, str)
+		if err != nil {
+			return err
+		}
+		err = WriteFileAtomic(path, []byte(str))
 		if err != nil {
 			return err
 		}
 		log.Infof("Replaced expressions from %s", path)
 	}
+	if len(migrationReq.PatchFile) > 0 {
+		err = os.WriteFile(migrationReq.PatchFile, []byte(patch.String()), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write the patch: %w", err)
+		}
+		log.Infof("Patch written to %s. Apply it using `git apply %s`", migrationReq.PatchFile, migrationReq.PatchFile)
+	}
 	data = make(map[string]interface{})
 	for path, expList := range notReplacedMap {
 		data[path] = strings.Join(expList, ", ")
@@ -252,35 +418,159 @@ func ReplaceCurrentGenExpressionsWithNextGen(*cli.Context) (err error) {
 	if len(data) > 0 {
 		renderTable("Expressions not replaced", data)
 	}
+	if manifest != nil {
+		log.Info("Run `harness-upgrade expressions revert` to undo the replacement")
+	}
 	return
 }
 
-func FindAllExpressions(str string) []string {
-	// Generic expressions
-	r := regexp.MustCompile(ExpressionPattern)
-	allExpressions := r.FindAllString(str, -1)
+// loadExpressionSettings validates the context & loads the custom expressions & the references of the secrets
+func loadExpressionSettings(ctx context.Context) error {
+	if len(migrationReq.ExpressionContext) > 0 {
+		err := assertAllowedValues(migrationReq.ExpressionContext, expressionContexts, "Invalid context. Allowed values are "+strings.Join(expressionContexts, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	if migrationReq.LookupSecrets && (len(migrationReq.Auth) == 0 || len(migrationReq.Account) == 0) {
+		return &ValidationError{Message: "Looking up secrets needs the api key & the account. Use --api-key & --account to provide them"}
+	}
+	if err := loadYamlFromFile(migrationReq.CustomExpressionsFile); err != nil {
+		return err
+	}
+	return loadSecretReferences(ctx)
+}
+
+// findExpressionFiles returns the files to look for expressions in based on the --path, --extensions, --include &
+// --exclude flags. The templates of the custom expressions file are not expressions, so the file is skipped.
+func findExpressionFiles() ([]string, error) {
+	extensions := Split(migrationReq.FileExtensions, ",")
+	for i, ext := range extensions {
+		extensions[i] = "." + ext
+	}
+	paths := migrationReq.Paths.Value()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := FindFiles(paths, FileFilter{
+		Extensions:  extensions,
+		Include:     migrationReq.Include.Value(),
+		Exclude:     migrationReq.Exclude.Value(),
+		NoGitIgnore: migrationReq.NoGitIgnore,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, file := range files {
+		if !isSameFile(file, migrationReq.CustomExpressionsFile) {
+			result = append(result, file)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// logToStderrForStdin writes the logs to stderr when reading from stdin so that the output can be piped
+func logToStderrForStdin(*cli.Context) error {
+	if slices.Contains(migrationReq.Paths.Value(), "-") {
+		log.SetOutput(os.Stderr)
+	}
+	return nil
+}
+
+// replaceExpressionsFromStdin writes the text read from stdin to stdout with the expressions replaced
+func replaceExpressionsFromStdin() error {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	str, notReplaced := ReplaceAllExpressions("", string(content))
+	if len(notReplaced) > 0 {
+		log.Warnf("Expressions not replaced - %s", strings.Join(notReplaced, ", "))
+	}
+	_, err = os.Stdout.WriteString(str)
+	return err
+}
 
-	// Secret expressions
-	r = regexp.MustCompile(SecretExpressionPattern)
-	allExpressions = append(allExpressions, r.FindAllString(str, -1)...)
+// FindAllExpressions returns the source of all the top level expressions in the order they appear
+func FindAllExpressions(str string) []string {
+	var allExpressions []string
+	for _, e := range ParseExpressions(str) {
+		allExpressions = append(allExpressions, e.Raw)
+	}
 	return allExpressions
 }
 
-func ReplaceAllExpressions(str string, expressions []string) (string, []string) {
+// ReplaceAllExpressions replaces every expression that has a Next Gen equivalent & returns the ones that do not. The
+// path of the file the content is from, if any, is used to find the scoped rules that apply.
+func ReplaceAllExpressions(filePath string, str string) (string, []string) {
+	// Only reviewers fail
+	str, notReplaced, _ := replaceExpressions(filePath, str, nil)
+	return str, notReplaced
+}
+
+// expressionReviewer decides the replacement of an expression given the Next Gen equivalent found, if any
+type expressionReviewer func(filePath string, content string, e *Expression, proposed string, ok bool) (string, bool, error)
+
+func replaceExpressions(filePath string, str string, review expressionReviewer) (string, []string, error) {
 	var notReplaced []string
-	for _, exp := range expressions {
-		temp := exp[2 : len(exp)-1]
-		val, ok := ExpressionsMap[temp]
-		if ok {
-			str = strings.ReplaceAll(str, exp, val)
-		} else if len(getDynamicExpressionKey(temp)) > 0 {
-			newVal := getDynamicExpressionValue(temp)
-			str = strings.ReplaceAll(str, exp, newVal)
-		} else {
-			notReplaced = append(notReplaced, exp)
+	var sb strings.Builder
+	last := 0
+	doc := parseYamlDocument(str)
+	for _, e := range ParseExpressions(str) {
+		val, ok := ConvertExpression(e, doc.scopeOf(filePath, e))
+		if review != nil {
+			var err error
+			if val, ok, err = review(filePath, str, e, val, ok); err != nil {
+				return "", nil, err
+			}
+		}
+		if !ok {
+			notReplaced = append(notReplaced, e.Raw)
+			continue
 		}
+		sb.WriteString(str[last:e.Start.Offset])
+		sb.WriteString(val)
+		last = e.End.Offset
 	}
-	return str, notReplaced
+	sb.WriteString(str[last:])
+	return sb.String(), Set(notReplaced), nil
+}
+
+// ConvertExpression returns the Next Gen equivalent of an expression in the scope it is found in
+func ConvertExpression(e *Expression, scope ExpressionScope) (string, bool) {
+	if !e.IsPath() {
+		return "", false
+	}
+	key := e.Key()
+	if val, ok := scope.lookup(key); ok {
+		return val, true
+	}
+	if val, ok := convertStepOutput(key, scope.Steps); ok {
+		return val, true
+	}
+	dynamic := scope.dynamicExpressions()
+	if len(getDynamicExpressionKey(key, dynamic)) > 0 {
+		// Nested expressions are converted as well, e.g. ${workflow.variables.${env.name}}
+		key = e.Body.render(func(n *Expression) string {
+			if val, ok := ConvertExpression(n, scope); ok {
+				return val
+			}
+			return n.Raw
+		})
+		return getDynamicExpressionValue(key, dynamic), true
+	}
+	return "", false
+}
+
+// convertRawExpression converts an expression that is not scoped to a file
+func convertRawExpression(raw string) (string, bool) {
+	expressions := ParseExpressions(raw)
+	if len(expressions) != 1 {
+		return "", false
+	}
+	return ConvertExpression(expressions[0], ExpressionScope{Context: migrationReq.ExpressionContext})
 }
 
 func renderSupportedExpressionsTable(data []string) {
@@ -289,17 +579,10 @@ func renderSupportedExpressionsTable(data []string) {
 	if len(data) > 0 {
 		var rows []table.Row
 		for _, exp := range data {
-			temp := exp[2 : len(exp)-1]
-			val, ok := ExpressionsMap[temp]
+			val, ok := convertRawExpression(exp)
 			check := "Yes"
 			if !ok {
-				dynamic := getDynamicExpressionKey(temp)
-				if len(dynamic) > 0 {
-					val = getDynamicExpressionValue(temp)
-				}
-				if len(dynamic) == 0 {
-					check = "No"
-				}
+				check = "No"
 			}
 			rows = append(rows, table.Row{exp, check, val})
 		}
@@ -323,8 +606,8 @@ func renderSupportedExpressionsTable(data []string) {
 
 }
 
-func getDynamicExpressionValue(key string) string {
-	k := getDynamicExpressionKey(key)
+func getDynamicExpressionValue(key string, dynamicExpressions map[string]interface{}) string {
+	k := getDynamicExpressionKey(key, dynamicExpressions)
 	var dynamic string
 	if strings.HasSuffix(k, "(") {
 		dynamic = strings.Replace(key, k, "", 1)
@@ -334,21 +617,33 @@ func getDynamicExpressionValue(key string) string {
 	} else {
 		dynamic = strings.Replace(key, k+".", "", 1)
 	}
-	return DynamicExpressions[k].(func(string2 string) string)(dynamic)
+	return dynamicExpressions[k].(func(string2 string) string)(dynamic)
 }
 
-func getDynamicExpressionKey(key string) string {
-	for exp, _ := range DynamicExpressions {
-		if strings.HasPrefix(key, exp) {
-			return exp
+// getDynamicExpressionKey returns the longest dynamic expression the key starts with. Dynamic expressions other than
+// calls are followed by a `.`, so that e.g. serviceVariable does not match serviceVariables.foo.
+func getDynamicExpressionKey(key string, dynamicExpressions map[string]interface{}) string {
+	var match string
+	for exp := range dynamicExpressions {
+		if !strings.HasPrefix(key, exp) || len(exp) <= len(match) {
+			continue
+		}
+		if strings.HasSuffix(exp, "(") || strings.HasPrefix(key, exp+".") {
+			match = exp
 		}
 	}
-	return ""
+	return match
 }
 
-func loadYamlFromFile(filePath string) {
-	data := LoadYamlFromFile(filePath)
-	for k, v := range data {
-		ExpressionsMap[k] = v
+func loadYamlFromFile(filePath string) error {
+	custom, err := LoadCustomExpressionsFromFile(filePath)
+	if err != nil {
+		return err
+	}
+	for k, v := range custom.Expressions {
+		CustomExpressionsMap[k] = v
 	}
+	ExpressionRules = append(ExpressionRules, custom.Scopes...)
+	CustomRules = append(CustomRules, custom.Rules...)
+	return nil
 }
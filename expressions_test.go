@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestReplaceAllExpressions converts the First Gen snippets in testdata/expressions & compares them with the Next Gen
+// output in the .expected.yaml file next to them. The First Gen expressions left in the output must be the ones
+// reported as not replaced.
+func TestReplaceAllExpressions(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "expressions", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, ".expected.yaml") {
+			continue
+		}
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".yaml"), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(file, ".yaml") + ".expected.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, notReplaced := ReplaceAllExpressions(filepath.ToSlash(file), string(src))
+			if got != string(want) {
+				t.Errorf("ReplaceAllExpressions(%s) =\n%s\nwant\n%s", file, got, want)
+			}
+
+			var left []string
+			for _, e := range ParseExpressions(string(want)) {
+				left = append(left, e.Raw)
+			}
+			sort.Strings(left)
+			sort.Strings(notReplaced)
+			if !reflect.DeepEqual(notReplaced, left) {
+				t.Errorf("not replaced = %v, want %v", notReplaced, left)
+			}
+		})
+	}
+}
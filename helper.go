@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/AlecAivazis/survey/v2"
 	log "github.com/sirupsen/logrus"
@@ -55,7 +58,7 @@ var urlMap = map[string]map[string]string{
 	},
 }
 
-func TextInput(question string) string {
+func TextInput(question string) (string, error) {
 	var text = ""
 	prompt := &survey.Input{
 		Message: question,
@@ -63,12 +66,27 @@ func TextInput(question string) string {
 	err := survey.AskOne(prompt, &text, survey.WithValidator(survey.Required))
 	if err != nil {
 		log.Error(err.Error())
-		os.Exit(0)
+		return "", &AbortedByUser{}
 	}
-	return text
+	return text, nil
 }
 
-func SelectInput(question string, options []string, defaultValue interface{}) string {
+// TextInputWithDefault asks for a text that defaults to the given value, which can be blank
+func TextInputWithDefault(question string, defaultValue string) (string, error) {
+	var text = ""
+	prompt := &survey.Input{
+		Message: question,
+		Default: defaultValue,
+	}
+	err := survey.AskOne(prompt, &text)
+	if err != nil {
+		log.Error(err.Error())
+		return "", &AbortedByUser{}
+	}
+	return text, nil
+}
+
+func SelectInput(question string, options []string, defaultValue interface{}) (string, error) {
 	var text = ""
 	prompt := &survey.Select{
 		Message: question,
@@ -78,9 +96,9 @@ func SelectInput(question string, options []string, defaultValue interface{}) st
 	err := survey.AskOne(prompt, &text, survey.WithValidator(survey.Required))
 	if err != nil {
 		log.Error(err.Error())
-		os.Exit(0)
+		return "", &AbortedByUser{}
 	}
-	return text
+	return text, nil
 }
 
 func ConfirmInput(question string) bool {
@@ -92,17 +110,25 @@ func ConfirmInput(question string) bool {
 	return confirm
 }
 
-func GetUrlWithQueryParams(environment string, service string, endpoint string, queryParams map[string]string) string {
+func GetUrlWithQueryParams(environment string, service string, endpoint string, queryParams map[string]string) (string, error) {
+	baseUrl, err := GetBaseUrl(environment, service)
+	if err != nil {
+		return "", err
+	}
 	params := ""
 	for k, v := range queryParams {
 		params = params + k + "=" + v + "&"
 	}
 
-	return fmt.Sprintf("%s/%s?%s", GetBaseUrl(environment, service), endpoint, params)
+	return fmt.Sprintf("%s/%s?%s", baseUrl, endpoint, params), nil
 }
 
-func GetUrl(environment string, service string, path string, accountId string) string {
-	return fmt.Sprintf("%s/%s?accountIdentifier=%s", GetBaseUrl(environment, service), path, accountId)
+func GetUrl(environment string, service string, path string, accountId string) (string, error) {
+	baseUrl, err := GetBaseUrl(environment, service)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s?accountIdentifier=%s", baseUrl, path, accountId), nil
 }
 
 func getOrDefault(value string, defaultValue string) string {
@@ -164,6 +190,39 @@ func WriteToFile(absFilePath string, content []byte) error {
 	return err
 }
 
+// WriteFileAtomic writes the content to a temporary file in the same folder & renames it over the file, so that the
+// file is never left half written. The mode of an existing file is kept.
+func WriteFileAtomic(filePath string, content []byte) (err error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(f.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filePath)
+}
+
 func ReadFile(absFilePath string) (string, error) {
 	d, err := os.ReadFile(absFilePath)
 	if err != nil {
@@ -212,6 +271,33 @@ func ToCamelCase(s string) string {
 	return n.String()
 }
 
+// ToLowerCase converts a name to a lower case identifier, e.g. `My Secret-1` to `my_secret_1`
+func ToLowerCase(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	n := strings.Builder{}
+	n.Grow(len(s))
+	for _, v := range []byte(s) {
+		if (v >= 'a' && v <= 'z') || (v >= '0' && v <= '9') {
+			n.WriteByte(v)
+		} else if n.Len() > 0 && !strings.HasSuffix(n.String(), "_") {
+			n.WriteByte('_')
+		}
+	}
+	id := strings.TrimSuffix(n.String(), "_")
+	if len(id) > 0 && id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+	return id
+}
+
+// ToIdentifier generates the identifier of a name in the format given by --identifier-format
+func ToIdentifier(name string) string {
+	if migrationReq.IdentifierCase == LowerCaseFormat {
+		return ToLowerCase(name)
+	}
+	return ToCamelCase(name)
+}
+
 func Split(str string, sep string) (result []string) {
 	if len(strings.TrimSpace(str)) == 0 {
 		return
@@ -223,12 +309,38 @@ func Split(str string, sep string) (result []string) {
 	return
 }
 
-func listEntities(entity string) (data []BaseEntityDetail, err error) {
-	url := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, entity, map[string]string{
+// forEachConcurrently calls fn for every item with at most concurrency calls running at the same time. No new calls
+// are made once the context is done.
+func forEachConcurrently(ctx context.Context, items []string, concurrency int, fn func(item string)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(item string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(item)
+		}(item)
+	}
+	wg.Wait()
+}
+
+func listEntities(ctx context.Context, entity string) (data []BaseEntityDetail, err error) {
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, entity, map[string]string{
 		AccountIdentifier: migrationReq.Account,
 		"appId":           migrationReq.AppId,
 	})
-	resp, err := Get(url, migrationReq.Auth)
+	if err != nil {
+		return
+	}
+	resp, err := Get(ctx, url, migrationReq.Auth)
 	if err != nil {
 		return
 	}
@@ -244,16 +356,16 @@ func listEntities(entity string) (data []BaseEntityDetail, err error) {
 	return
 }
 
-func GetBaseUrl(environment string, service string) string {
+func GetBaseUrl(environment string, service string) (string, error) {
 	if environment == "Prod1" || environment == "Prod2" {
 		environment = "Prod"
 	}
 	if environment != SelfManaged {
 		url := urlMap[environment][service]
 		if len(url) == 0 {
-			log.Fatalf("invalid environment value - %s", environment)
+			return "", &ValidationError{Message: fmt.Sprintf("invalid environment value - %s", environment)}
 		}
-		return url
+		return url, nil
 	}
 	var url string
 	switch service {
@@ -266,13 +378,13 @@ func GetBaseUrl(environment string, service string) string {
 	case MigratorService:
 		url = migrationReq.BaseUrl + "/ng-migration/api/ng-migration"
 	default:
-		panic("Unknown service! Please contact Harness support")
+		return "", &ValidationError{Message: fmt.Sprintf("Unknown service %s! Please contact Harness support", service)}
 	}
 	log.Debugf("BaseUrl for SelfManaged - %s", url)
-	return url
+	return url, nil
 }
 
-func GetEntityIds(entity string, idsString string, namesString string) ([]string, error) {
+func GetEntityIds(ctx context.Context, entity string, idsString string, namesString string) ([]string, error) {
 	ids := Split(idsString, ",")
 	if len(ids) > 0 {
 		return ids, nil
@@ -281,7 +393,7 @@ func GetEntityIds(entity string, idsString string, namesString string) ([]string
 	if len(names) == 0 {
 		return nil, nil
 	}
-	nameToIdMap, err := GetEntityNameIdMap(entity)
+	nameToIdMap, err := GetEntityNameIdMap(ctx, entity)
 	if err != nil {
 		return nil, err
 	}
@@ -297,8 +409,8 @@ func GetEntityIds(entity string, idsString string, namesString string) ([]string
 	return result, nil
 }
 
-func GetEntityNameIdMap(entity string) (map[string]string, error) {
-	items, err := listEntities(entity)
+func GetEntityNameIdMap(ctx context.Context, entity string) (map[string]string, error) {
+	items, err := listEntities(ctx, entity)
 	if err != nil {
 		return nil, err
 	}
@@ -310,13 +422,16 @@ func GetEntityNameIdMap(entity string) (map[string]string, error) {
 	return nameToIdMap, err
 }
 
-func MigrateEntities(promptConfirm bool, scopes []string, pluralValue string, entityType EntityType) (err error) {
-	promptConfirm = PromptOrgAndProject(scopes) || promptConfirm
+func MigrateEntities(ctx context.Context, promptConfirm bool, scopes []string, pluralValue string, entityType EntityType) (err error) {
+	promptConfirm, err = PromptOrgAndProject(scopes, promptConfirm)
+	if err != nil {
+		return err
+	}
 	logMigrationDetails()
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
@@ -324,12 +439,12 @@ func MigrateEntities(promptConfirm bool, scopes []string, pluralValue string, en
 	var ids []string
 	if !migrationReq.All {
 		importType = "SPECIFIC"
-		ids, err = GetEntityIds(pluralValue, migrationReq.Identifiers, migrationReq.Names)
+		ids, err = GetEntityIds(ctx, pluralValue, migrationReq.Identifiers, migrationReq.Names)
 		if err != nil {
-			log.Fatal(fmt.Sprintf("Failed to get ids of the %s", pluralValue))
+			return fmt.Errorf("failed to get ids of the %s: %w", pluralValue, err)
 		}
 		if len(ids) == 0 {
-			log.Fatal(fmt.Sprintf("No %s found with given names/ids", pluralValue))
+			return &ValidationError{Message: fmt.Sprintf("No %s found with given names/ids", pluralValue)}
 		}
 	}
 	log.Info(fmt.Sprintf("Importing the %s....", pluralValue))
@@ -337,88 +452,80 @@ func MigrateEntities(promptConfirm bool, scopes []string, pluralValue string, en
 	if len(migrationReq.AppId) > 0 {
 		scope = AppScope
 	}
-	CreateEntities(getReqBody(entityType, Filter{
+	err = createEntities(ctx, entityType, Filter{
 		AppId: migrationReq.AppId,
 		Type:  importType,
 		Ids:   ids,
 		Scope: scope,
-	}))
+	})
+	if err != nil {
+		return err
+	}
 	log.Info(fmt.Sprintf("Imported the %s.", pluralValue))
 
 	return nil
 }
 
-func LoadYamlFromFile(filePath string) map[string]string {
-	filePath = strings.TrimSpace(filePath)
-	if len(filePath) == 0 {
-		return nil
-	}
-	yFile, err := os.ReadFile(filePath)
+// LoadYamlFromFile returns the expressions of the custom expressions file that are sent along with the migration
+// requests. Its rules & scopes are only applied by the expressions command as the migration does not support them.
+func LoadYamlFromFile(filePath string) (map[string]string, error) {
+	custom, err := LoadCustomExpressionsFromFile(filePath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	data := make(map[string]string)
-	err = yaml.Unmarshal(yFile, &data)
-	if err != nil {
-		log.Fatal(err)
+	if len(custom.Rules) > 0 || len(custom.Scopes) > 0 {
+		log.Warnf("The %d rules & %d scopes of %s are not applied by the migration, only its %d expressions are. Use the expressions command to apply them to the files after the migration", len(custom.Rules), len(custom.Scopes), filePath, len(custom.Expressions))
 	}
-	log.Infof("Successfully loaded %d custom expressions from the file", len(data))
-	return data
+	return custom.Expressions, nil
 }
 
-func LoadOverridesFromFile(filePath string) map[string]EntityOverrideInput {
+func LoadOverridesFromFile(ctx context.Context, filePath string) (map[string]EntityOverrideInput, error) {
 	filePath = strings.TrimSpace(filePath)
 	if len(filePath) == 0 {
-		return nil
+		return nil, nil
 	}
-	yFile, err := os.ReadFile(filePath)
+	data, problems, err := readOverrides(filePath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	var data OverrideFileData
-	err = yaml.Unmarshal(yFile, &data)
-	if err != nil {
-		log.Fatal(err)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Message: fmt.Sprintf("invalid overrides file %s - %s", filePath, strings.Join(problems, "; "))}
 	}
-	log.Infof("Successfully loaded %d overrides & %d settings from the file", len(data.Overrides), len(data.Settings))
+	log.Infof("Successfully loaded %d overrides, %d override rules & %d settings from the file", len(data.Overrides), len(data.Rules), len(data.Settings))
 
-	if len(data.Overrides) == 0 {
-		return nil
+	if len(data.Overrides) == 0 && len(data.Rules) == 0 {
+		return nil, nil
 	}
 
 	var overrides = make(map[string]EntityOverrideInput)
-	var nameToIdMap = make(map[string]map[string]string)
-	for i, override := range data.Overrides {
-		assertNotBlank(override.Type, fmt.Sprintf("Type cannot be blank in overrides for index - %d", i))
-		assertNotAllBlank(fmt.Sprintf("Name, Identifier & Scope are blank in overrides for index - %d", i), override.Name, override.Identifier, override.Scope)
-		assertAllowedValues(override.Type, []string{UserGroups, Template, Connector, Secret, Service, Environment, Workflow, Pipeline}, fmt.Sprintf("Only a few types of entities support overrides for index %d", i))
-		if len(strings.TrimSpace(override.ID)) > 0 {
-			overrides[fmt.Sprintf("CgEntityId(id=%s, type=%s)", override.ID, override.Type)] = EntityOverrideInput{
-				Name:       override.Name,
-				Identifier: override.Identifier,
-				Scope:      override.Scope,
-			}
-		} else {
-			assertNotBlank(override.FirstGenName, fmt.Sprintf("Both firstGen name & ID fields cannot be blank in overrides for index %d", i))
-			if len(nameToIdMap[override.Type]) == 0 {
-				nameToIdMap[override.Type], err = GetEntityNameIdMap(GetEndpointFromType(override.Type))
-				if err != nil {
-					log.Fatal(fmt.Sprintf("Failed to fetch ids from names for - %s", override.Type), err)
-				}
+	entities := make(entityCache)
+	for _, override := range data.Overrides {
+		id := strings.TrimSpace(override.ID)
+		if len(id) == 0 {
+			items, err := entities.list(ctx, override.Type)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch ids from names for - %s: %w", override.Type, err)
 			}
-			id, ok := nameToIdMap[override.Type][override.FirstGenName]
+			entity, ok := findOverriddenEntity(items, override)
 			if !ok {
-				log.Fatal(fmt.Sprintf("Failed to fetch id for name %s of type - %s", override.FirstGenName, override.Type))
-			}
-			overrides[fmt.Sprintf("CgEntityId(id=%s, type=%s)", id, override.Type)] = EntityOverrideInput{
-				Name:       override.Name,
-				Identifier: override.Identifier,
-				Scope:      override.Scope,
+				return nil, &ValidationError{Message: fmt.Sprintf("Failed to fetch id for name %s of type - %s", override.FirstGenName, override.Type)}
 			}
+			id = entity.Id
+		}
+		overrides[fmt.Sprintf("CgEntityId(id=%s, type=%s)", id, override.Type)] = EntityOverrideInput{
+			Name:       override.Name,
+			Identifier: override.Identifier,
+			Scope:      override.Scope,
+		}
+	}
+	// Entities overridden explicitly take precedence over the rules
+	if len(data.Rules) > 0 {
+		if err = expandOverrideRules(ctx, data.Rules, overrides, entities); err != nil {
+			return nil, err
 		}
 	}
 
-	return overrides
+	return overrides, nil
 }
 
 func GetEndpointFromType(entityType string) string {
@@ -428,40 +535,33 @@ func GetEndpointFromType(entityType string) string {
 	return strings.ToLower(entityType + "s")
 }
 
-func LoadSettingsFromFile(filePath string) []Setting {
+func LoadSettingsFromFile(filePath string) ([]Setting, error) {
 	filePath = strings.TrimSpace(filePath)
 	if len(filePath) == 0 {
-		return nil
+		return nil, nil
 	}
 	yFile, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
 	var data OverrideFileData
 	err = yaml.Unmarshal(yFile, &data)
 	if err != nil {
-		log.Fatal(err)
+		return nil, &ValidationError{Message: fmt.Sprintf("invalid overrides file %s - %s", filePath, err)}
 	}
-	return data.Settings
+	return data.Settings, nil
 }
 
-func assertNotBlank(value string, message string) {
+func assertNotBlank(value string, message string) error {
 	if len(strings.TrimSpace(value)) == 0 {
-		log.Fatal(message)
+		return &ValidationError{Message: message}
 	}
+	return nil
 }
 
-func assertAllowedValues(value string, allowed []string, message string) {
+func assertAllowedValues(value string, allowed []string, message string) error {
 	if !slices.Contains(allowed, value) {
-		log.Fatal(message)
+		return &ValidationError{Message: message}
 	}
-}
-
-func assertNotAllBlank(message string, values ...*string) {
-	for _, value := range values {
-		if value != nil && len(strings.TrimSpace(*value)) > 0 {
-			return
-		}
-	}
-	log.Fatal(message)
+	return nil
 }
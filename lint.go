@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+)
+
+// lintKey is the key the dynamic expressions are called with to find the families of the expressions they return
+const lintKey = "lintKey"
+
+// nextGenBuiltinExpressions are Next Gen expressions that no First Gen expression converts to but are valid anyway
+var nextGenBuiltinExpressions = []string{"input"}
+
+// NextGenExpression is a Next Gen expression `<+...>` found in a text
+type NextGenExpression struct {
+	Start Position
+	Raw   string
+	// Path is the leading path of the body, e.g. `pipeline.variables.foo` in `<+pipeline.variables.foo.length()>`.
+	// It is empty if the body does not start with a path.
+	Path string
+	// Call reports if the path is followed by a method call
+	Call bool
+}
+
+// KnownExpressions are the Next Gen expressions the conversion of First Gen expressions can produce. Paths are
+// known as is & prefixes along with anything that follows them.
+type KnownExpressions struct {
+	Paths    map[string]bool
+	Prefixes []string
+}
+
+// LintExpressions reports the Next Gen expressions in the files that no First Gen expression converts to, which are
+// usually typos introduced when editing the files by hand
+func LintExpressions(ctx *cli.Context) error {
+	if slices.Contains(migrationReq.Paths.Value(), "-") {
+		return &ValidationError{Message: "Reading from stdin is not supported by the linter"}
+	}
+	err := loadExpressionSettings(ctx.Context)
+	if err != nil {
+		return err
+	}
+	files, err := findExpressionFiles()
+	if err != nil {
+		return err
+	}
+
+	known := getKnownExpressions()
+	unknown := 0
+	unknownFiles := 0
+	for _, path := range files {
+		content, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+		found := 0
+		for _, e := range ParseNextGenExpressions(content) {
+			if known.matches(e) {
+				continue
+			}
+			log.Errorf("%s:%d:%d: unknown expression %s", path, e.Start.Line, e.Start.Column, e.Raw)
+			found++
+		}
+		if found > 0 {
+			unknown += found
+			unknownFiles++
+		}
+	}
+	if unknown > 0 {
+		return &ValidationError{Message: fmt.Sprintf("Found %d unknown expressions in %d files", unknown, unknownFiles)}
+	}
+	log.Infof("No unknown expressions found in %d files", len(files))
+	return nil
+}
+
+// getKnownExpressions collects the expressions of the ExpressionsMap, the DynamicExpressions & the custom expressions
+// file along with the ones of every context
+func getKnownExpressions() KnownExpressions {
+	known := KnownExpressions{Paths: make(map[string]bool)}
+	addValue := func(value string) {
+		for _, e := range ParseNextGenExpressions(value) {
+			if len(e.Path) > 0 && !e.Call {
+				known.Paths[e.Path] = true
+			}
+		}
+	}
+	addTemplate := func(value string) {
+		// The capture groups of a rule are not known, so everything up to the first of them is a prefix
+		if i := strings.IndexByte(value, '$'); i >= 0 {
+			known.addPrefix(value[:i])
+			return
+		}
+		addValue(value)
+	}
+	addDynamic := func(dynamicExpressions map[string]interface{}) {
+		for _, fn := range dynamicExpressions {
+			known.addPrefix(fn.(func(string) string)(lintKey))
+		}
+	}
+
+	for _, path := range nextGenBuiltinExpressions {
+		known.Paths[path] = true
+	}
+	for _, value := range ExpressionsMap {
+		addValue(value)
+	}
+	addDynamic(DynamicExpressions)
+	// The outputs of any step, as the identifiers of the steps are the ones of the workflows
+	known.addPrefix(stepOutput(lintKey, lintKey))
+	for _, expressions := range ContextExpressions {
+		for _, value := range expressions {
+			addValue(value)
+		}
+	}
+	for _, dynamicExpressions := range ContextDynamicExpressions {
+		addDynamic(dynamicExpressions)
+	}
+	for _, value := range CustomExpressionsMap {
+		addValue(value)
+	}
+	for _, rule := range CustomRules {
+		addTemplate(rule.Value)
+	}
+	for _, scope := range ExpressionRules {
+		for _, value := range scope.Expressions {
+			addValue(value)
+		}
+		for _, rule := range scope.Rules {
+			addTemplate(rule.Value)
+		}
+	}
+	return known
+}
+
+// addPrefix adds the family of a Next Gen expression, which is what comes before the key or the arguments of a call,
+// e.g. `serviceVariables.` for `<+serviceVariables.lintKey>` & `secrets.getValue(` for
+// `<+secrets.getValue("org.lintKey")>`
+func (k *KnownExpressions) addPrefix(value string) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "<+")
+	if i := strings.Index(value, lintKey); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.IndexByte(value, '('); i >= 0 {
+		value = value[:i+1]
+	}
+	if len(value) > 0 && !slices.Contains(k.Prefixes, value) {
+		k.Prefixes = append(k.Prefixes, value)
+	}
+}
+
+// matches reports if the expression is known. Expressions that do not start with a path, like the ones calling
+// methods of other expressions, are not checked.
+func (k KnownExpressions) matches(e NextGenExpression) bool {
+	if len(e.Path) == 0 {
+		return true
+	}
+	candidates := []string{e.Path}
+	if e.Call {
+		// Either a call like secrets.getValue( or a method of a known expression like pipeline.name.toLowerCase()
+		candidates = []string{e.Path + "("}
+		if i := strings.LastIndexByte(e.Path, '.'); i > 0 {
+			candidates = append(candidates, e.Path[:i])
+		}
+	}
+	for _, candidate := range candidates {
+		if k.Paths[candidate] {
+			return true
+		}
+		for _, prefix := range k.Prefixes {
+			if strings.HasPrefix(candidate, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseNextGenExpressions finds all the Next Gen expressions in the source, including the ones within other
+// expressions, in the order they start
+func ParseNextGenExpressions(src string) []NextGenExpression {
+	p := &parser{src: src, lines: lineOffsets(src)}
+	var expressions []NextGenExpression
+	for i := 0; i < len(src)-1; i++ {
+		if src[i] != '<' || src[i+1] != '+' {
+			continue
+		}
+		end := p.findNextGenEnd(i + 2)
+		if end < 0 {
+			continue
+		}
+		path, call := nextGenPath(src[i+2 : end])
+		expressions = append(expressions, NextGenExpression{Start: p.position(i), Raw: src[i : end+1], Path: path, Call: call})
+	}
+	return expressions
+}
+
+// findNextGenEnd returns the offset of the `>` that closes the Next Gen expression whose body starts at the offset
+func (p *parser) findNextGenEnd(offset int) int {
+	depth := 1
+	var quote byte
+	for i := offset; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '<' && i+1 < len(p.src) && p.src[i+1] == '+':
+			depth++
+			i++
+		case c == '>':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case c == '\n':
+			// Expressions do not span lines
+			return -1
+		}
+	}
+	return -1
+}
+
+// nextGenPath returns the leading path of the body of a Next Gen expression & if it is followed by a call
+func nextGenPath(body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if len(body) == 0 || !isIdentStart(body[0]) {
+		return "", false
+	}
+	i := 0
+	for i < len(body) {
+		c := body[i]
+		if isIdentPart(c) || c == '.' {
+			i++
+			continue
+		}
+		if c == '[' {
+			// Index accesses like pcf.newAppRoutes[0] are part of the path
+			if j := strings.IndexByte(body[i:], ']'); j > 0 {
+				i += j + 1
+				continue
+			}
+		}
+		break
+	}
+	path := strings.TrimSuffix(body[:i], ".")
+	rest := strings.TrimSpace(body[i:])
+	return path, strings.HasPrefix(rest, "(")
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -15,53 +19,99 @@ type cliFnWrapper func(ctx *cli.Context) error
 
 // Note: All prompt responses will be added to this
 var migrationReq = struct {
-	Auth                  string `survey:"auth"`
-	Environment           string `survey:"environment"`
-	Account               string `survey:"account"`
-	SecretScope           string `survey:"secretScope"`
-	ConnectorScope        string `survey:"connectorScope"`
-	WorkflowScope         string `survey:"workflowScope"`
-	PipelineScope         string `survey:"pipelineScope"`
-	TemplateScope         string `survey:"templateScope"`
-	UserGroupScope        string `survey:"userGroupScope"`
-	OrgIdentifier         string `survey:"org"`
-	ProjectIdentifier     string `survey:"project"`
-	AppId                 string `survey:"appId"`
-	AllAppEntities        bool   `survey:"all"`
-	WorkflowIds           string `survey:"workflowIds"`
-	PipelineIds           string `survey:"pipelineIds"`
-	TriggerIds            string `survey:"triggerIds"`
-	File                  string `survey:"load"`
-	IdentifierCase        string `survey:"identifierCase"`
-	LogLevel              string `survey:"logLevel"`
-	Json                  bool   `survey:"json"`
-	AllowInsecureReq      bool   `survey:"insecure"`
-	ProjectName           string `survey:"projectName"`
-	OrgName               string `survey:"orgName"`
-	UrlNG                 string `survey:"urlNG"`
-	UrlCG                 string `survey:"urlCG"`
-	DryRun                bool   `survey:"dryRun"`
-	FileExtensions        string `survey:"fileExtensions"`
-	CustomExpressionsFile string `survey:"customExpressionsFile"`
-	OverrideFile          string `survey:"overrideFile"`
-	ExportFolderPath      string `survey:"export"`
-	CsvFile               string `survey:"csv"`
-	Names                 string `survey:"names"`
-	Identifiers           string `survey:"identifiers"`
-	All                   bool   `survey:"all"`
-	AsPipelines           bool   `survey:"asPipelines"`
-	TargetAccount         string `survey:"targetAccount"`
-	TargetAuthToken       string `survey:"targetAuth"`
-	BaseUrl               string `survey:"baseUrl"`
-	TargetGatewayUrl      string `survey:"targetGatewayUrl"`
-	Force                 bool   `survey:"force"`
+	Auth                    string          `survey:"auth"`
+	Environment             string          `survey:"environment"`
+	Account                 string          `survey:"account"`
+	SecretScope             string          `survey:"secretScope"`
+	ConnectorScope          string          `survey:"connectorScope"`
+	WorkflowScope           string          `survey:"workflowScope"`
+	PipelineScope           string          `survey:"pipelineScope"`
+	TemplateScope           string          `survey:"templateScope"`
+	UserGroupScope          string          `survey:"userGroupScope"`
+	OrgIdentifier           string          `survey:"org"`
+	ProjectIdentifier       string          `survey:"project"`
+	AppId                   string          `survey:"appId"`
+	AllAppEntities          bool            `survey:"all"`
+	WorkflowIds             string          `survey:"workflowIds"`
+	PipelineIds             string          `survey:"pipelineIds"`
+	TriggerIds              string          `survey:"triggerIds"`
+	File                    string          `survey:"load"`
+	IdentifierCase          string          `survey:"identifierCase"`
+	LogLevel                string          `survey:"logLevel"`
+	Json                    bool            `survey:"json"`
+	AllowInsecureReq        bool            `survey:"insecure"`
+	ProjectName             string          `survey:"projectName"`
+	OrgName                 string          `survey:"orgName"`
+	UrlNG                   string          `survey:"urlNG"`
+	UrlCG                   string          `survey:"urlCG"`
+	DryRun                  bool            `survey:"dryRun"`
+	FileExtensions          string          `survey:"fileExtensions"`
+	CustomExpressionsFile   string          `survey:"customExpressionsFile"`
+	OverrideFile            string          `survey:"overrideFile"`
+	ExportFolderPath        string          `survey:"export"`
+	CsvFile                 string          `survey:"csv"`
+	Names                   string          `survey:"names"`
+	Identifiers             string          `survey:"identifiers"`
+	All                     bool            `survey:"all"`
+	AsPipelines             bool            `survey:"asPipelines"`
+	TargetAccount           string          `survey:"targetAccount"`
+	TargetAuthToken         string          `survey:"targetAuth"`
+	BaseUrl                 string          `survey:"baseUrl"`
+	TargetGatewayUrl        string          `survey:"targetGatewayUrl"`
+	Force                   bool            `survey:"force"`
+	MaxRetries              int             `survey:"maxRetries"`
+	RetryBackoff            time.Duration   `survey:"retryBackoff"`
+	Timeout                 time.Duration   `survey:"timeout"`
+	RequestId               string          `survey:"requestId"`
+	RequestKind             string          `survey:"requestKind"`
+	Wait                    bool            `survey:"wait"`
+	ReportFile              string          `survey:"report"`
+	ReportFormat            string          `survey:"reportFormat"`
+	Fresh                   bool            `survey:"fresh"`
+	PlanFile                string          `survey:"planFile"`
+	Parallelism             int             `survey:"parallelism"`
+	NoDeps                  bool            `survey:"noDeps"`
+	WithDeps                bool            `survey:"withDeps"`
+	DepsFormat              string          `survey:"depsFormat"`
+	RateLimit               float64         `survey:"rateLimit"`
+	ServiceRateLimits       string          `survey:"serviceRateLimits"`
+	Concurrency             int             `survey:"concurrency"`
+	Diff                    bool            `survey:"diff"`
+	PatchFile               string          `survey:"patch"`
+	Paths                   cli.StringSlice `survey:"paths"`
+	Include                 cli.StringSlice `survey:"include"`
+	Exclude                 cli.StringSlice `survey:"exclude"`
+	NoGitIgnore             bool            `survey:"noGitIgnore"`
+	BackupSuffix            string          `survey:"backupSuffix"`
+	ExpressionContext       string          `survey:"expressionContext"`
+	Validate                bool            `survey:"validate"`
+	ExpressionsReportFormat string          `survey:"expressionsReportFormat"`
+	ExpressionsReportFile   string          `survey:"expressionsReportFile"`
+	Interactive             bool            `survey:"interactive"`
+	LookupSecrets           bool            `survey:"lookupSecrets"`
+	OverrideTypes           string          `survey:"overrideTypes"`
+	OverridesOut            string          `survey:"overridesOut"`
+	Offline                 bool            `survey:"offline"`
+	ConfigContext           string          `survey:"context"`
 }{}
 
-func getReqBody(entityType EntityType, filter Filter) RequestBody {
+func getReqBody(ctx context.Context, entityType EntityType, filter Filter) (RequestBody, error) {
+	overrides, err := LoadOverridesFromFile(ctx, migrationReq.OverrideFile)
+	if err != nil {
+		return RequestBody{}, err
+	}
+	expressions, err := LoadYamlFromFile(migrationReq.CustomExpressionsFile)
+	if err != nil {
+		return RequestBody{}, err
+	}
+	settings, err := LoadSettingsFromFile(migrationReq.OverrideFile)
+	if err != nil {
+		return RequestBody{}, err
+	}
 	inputs := Inputs{
-		Overrides:   LoadOverridesFromFile(migrationReq.OverrideFile),
-		Expressions: LoadYamlFromFile(migrationReq.CustomExpressionsFile),
-		Settings:    LoadSettingsFromFile(migrationReq.OverrideFile),
+		Overrides:   overrides,
+		Expressions: expressions,
+		Settings:    settings,
 		Defaults: Defaults{
 			Secret:                EntityDefaults{Scope: getOrDefault(migrationReq.SecretScope, Project)},
 			SecretManager:         EntityDefaults{Scope: getOrDefault(migrationReq.SecretScope, Project)},
@@ -79,7 +129,16 @@ func getReqBody(entityType EntityType, filter Filter) RequestBody {
 		AuthToken:         migrationReq.TargetAuthToken,
 		GatewayUrl:        migrationReq.TargetGatewayUrl,
 	}
-	return RequestBody{Inputs: inputs, DestinationDetails: destination, EntityType: entityType, Filter: filter, IdentifierCaseFormat: migrationReq.IdentifierCase}
+	return RequestBody{Inputs: inputs, DestinationDetails: destination, EntityType: entityType, Filter: filter, IdentifierCaseFormat: migrationReq.IdentifierCase}, nil
+}
+
+// createEntities builds the request body for the entity type & waits for the migration to complete
+func createEntities(ctx context.Context, entityType EntityType, filter Filter) error {
+	body, err := getReqBody(ctx, entityType, filter)
+	if err != nil {
+		return err
+	}
+	return CreateEntities(ctx, body)
 }
 
 func logMigrationDetails() {
@@ -98,7 +157,7 @@ func cliWrapper(fn cliFnWrapper, ctx *cli.Context) error {
 	if len(migrationReq.LogLevel) > 0 {
 		level, err := log.ParseLevel(migrationReq.LogLevel)
 		if err != nil {
-			log.Fatal("Invalid log level")
+			return &ValidationError{Message: "Invalid log level"}
 		}
 		log.SetLevel(level)
 	}
@@ -106,7 +165,34 @@ func cliWrapper(fn cliFnWrapper, ctx *cli.Context) error {
 	if migrationReq.Json {
 		log.SetFormatter(&log.JSONFormatter{})
 	}
-	return fn(ctx)
+
+	if err := validateReportFormat(); err != nil {
+		return err
+	}
+
+	if err := initRateLimiters(); err != nil {
+		return err
+	}
+
+	// Cancel all in-flight requests & poll loops on interrupt or when the overall timeout is reached
+	runCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if migrationReq.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, migrationReq.Timeout)
+		defer cancel()
+	}
+	ctx.Context = runCtx
+	initState()
+	err := fn(ctx)
+	if err == nil {
+		clearState()
+	}
+	// The report is written even if the command fails so that partial results are not lost
+	if reportErr := writeReport(); reportErr != nil {
+		log.WithError(reportErr).Error("Failed to write the migration report")
+	}
+	return err
 }
 
 func init() {
@@ -205,6 +291,11 @@ func main() {
 			Usage:       "`FILE` to load flags from",
 			Destination: &migrationReq.File,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "context",
+			Usage:       "`NAME` of the context in the config file to take the flags from. Defaults to the current context",
+			Destination: &migrationReq.ConfigContext,
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "insecure",
 			Usage:       "allow insecure API requests. This is automatically set to true if environment is Dev",
@@ -253,6 +344,60 @@ func main() {
 			Usage:       "provide a `FILE` to load overrides",
 			Destination: &migrationReq.OverrideFile,
 		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "max-retries",
+			Usage:       "`COUNT` of times to retry idempotent API calls on throttling, gateway errors & connection resets",
+			Value:       3,
+			Destination: &migrationReq.MaxRetries,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "retry-backoff",
+			Usage:       "base `DURATION` to wait before the first retry. Doubles with every subsequent retry",
+			Value:       time.Second,
+			Destination: &migrationReq.RetryBackoff,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "overall `DURATION` after which the command stops waiting for the migration. Disabled by default",
+			Destination: &migrationReq.Timeout,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "fresh",
+			Usage:       "ignore the state of a previous incomplete run of the same command & start afresh",
+			Destination: &migrationReq.Fresh,
+		}),
+		altsrc.NewFloat64Flag(&cli.Float64Flag{
+			Name:        "rate-limit",
+			Usage:       "maximum `REQUESTS_PER_SECOND` made to every Harness service. Disabled by default",
+			Destination: &migrationReq.RateLimit,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "service-rate-limits",
+			Usage:       "maximum requests per second made to specific services as `NextGen=5,Pipeline=2,Template=2,Migrator=1`. Overrides --rate-limit",
+			Destination: &migrationReq.ServiceRateLimits,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "no-deps",
+			Usage:       "do not import the services, environments, workflows & pipelines of the app that are needed before importing workflows, pipelines or triggers",
+			Destination: &migrationReq.NoDeps,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "with-deps",
+			Usage:       "import what workflows, pipelines or triggers depend on even when only some of them are imported with their ids or names",
+			Destination: &migrationReq.WithDeps,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "report",
+			Usage:       "`FILE` to write the results of the migration to",
+			Destination: &migrationReq.ReportFile,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "report-format",
+			Usage:       "`FORMAT` of the report. Possible values - json, csv, junit, markdown",
+			Value:       JsonReport,
+			DefaultText: JsonReport,
+			Destination: &migrationReq.ReportFormat,
+		}),
 	}
 	app := &cli.App{
 		Name:                 "harness-upgrade",
@@ -283,6 +428,109 @@ func main() {
 					return cliWrapper(GetAppSummary, context)
 				},
 			},
+			{
+				Name:  "deps",
+				Usage: "Print the dependency graph of an app in the order the entities are migrated",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "format",
+						Usage:       "`FORMAT` of the graph. Possible values - text, dot",
+						Value:       TextGraph,
+						DefaultText: TextGraph,
+						Destination: &migrationReq.DepsFormat,
+					},
+				},
+				Action: func(context *cli.Context) error {
+					return cliWrapper(PrintDependencies, context)
+				},
+			},
+			{
+				Name:    "status",
+				Aliases: []string{"attach"},
+				Usage:   "Get the status of an existing request & render its results once done",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "request-id",
+						Usage:       "`REQUEST_ID` of the request that was logged when it was queued",
+						Destination: &migrationReq.RequestId,
+					},
+					&cli.StringFlag{
+						Name:        "kind",
+						Usage:       "`KIND` of the request. Possible values - save, summary",
+						Value:       SaveRequest,
+						DefaultText: SaveRequest,
+						Destination: &migrationReq.RequestKind,
+					},
+					&cli.BoolFlag{
+						Name:        "wait",
+						Usage:       "if set will wait until the request is done",
+						Destination: &migrationReq.Wait,
+					},
+				},
+				Action: func(context *cli.Context) error {
+					return cliWrapper(GetRequestStatus, context)
+				},
+			},
+			{
+				Name:  "resume",
+				Usage: "Resume the last incomplete run. Completed steps are skipped & in-flight requests are re-attached to",
+				// The resumed command runs through cliWrapper itself
+				Action: resumeLastRun,
+			},
+			{
+				Name:  "plan",
+				Usage: "Validate a migration plan file & print the ordered steps it will run",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "file",
+						Usage:       "`FILE` of the migration plan",
+						Destination: &migrationReq.PlanFile,
+					},
+				},
+				Action: func(context *cli.Context) error {
+					return cliWrapper(ShowPlan, context)
+				},
+			},
+			{
+				Name:  "apply",
+				Usage: "Run all the steps of a migration plan file in order",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "file",
+						Usage:       "`FILE` of the migration plan",
+						Destination: &migrationReq.PlanFile,
+					},
+				},
+				Action: func(context *cli.Context) error {
+					return cliWrapper(ApplyPlan, context)
+				},
+			},
+			{
+				Name:  "migrate-bulk",
+				Usage: "Import multiple apps at the same time using the files generated when projects are created in bulk",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "from",
+						Usage:       "`FOLDER_PATH` of the files generated when projects are created in bulk",
+						Destination: &migrationReq.ExportFolderPath,
+					},
+					&cli.IntFlag{
+						Name:        "parallelism",
+						Usage:       "`N` apps that are migrated at the same time",
+						Value:       4,
+						DefaultText: "4",
+						Destination: &migrationReq.Parallelism,
+					},
+					&cli.BoolFlag{
+						Name:        "all",
+						Usage:       "if set will migrate all workflows & pipelines of every app",
+						Destination: &migrationReq.AllAppEntities,
+					},
+				},
+				Action: func(context *cli.Context) error {
+					return cliWrapper(MigrateBulk, context)
+				},
+			},
 			{
 				Name:  "user-groups",
 				Usage: "Import user groups from First Gen to Next Gen",
@@ -473,6 +721,13 @@ func main() {
 						Usage:       "`NAMES` of the next gen pipeline",
 						Destination: &migrationReq.Names,
 					},
+					&cli.IntFlag{
+						Name:        "concurrency",
+						Usage:       "`N` pipelines that are removed at the same time",
+						Value:       1,
+						DefaultText: "1",
+						Destination: &migrationReq.Concurrency,
+					},
 				},
 				Subcommands: []*cli.Command{
 					{
@@ -531,10 +786,195 @@ func main() {
 						DefaultText: "json,yaml,yml",
 						Destination: &migrationReq.FileExtensions,
 					},
+					&cli.BoolFlag{
+						Name:        "diff",
+						Usage:       "if set will print a colored unified diff of the replacements without changing the files",
+						Destination: &migrationReq.Diff,
+					},
+					&cli.StringFlag{
+						Name:        "patch",
+						Usage:       "`FILE` to write a patch of the replacements to that can be applied using git apply. Files are not changed",
+						Destination: &migrationReq.PatchFile,
+					},
+					&cli.StringSliceFlag{
+						Name:        "path",
+						Usage:       "`PATH` of a file or directory to look for expressions in. Can be repeated. Use - to read from stdin & write the replaced text to stdout. defaults to the current directory",
+						Destination: &migrationReq.Paths,
+					},
+					&cli.StringSliceFlag{
+						Name:        "include",
+						Usage:       "glob `PATTERN` of the files to look for expressions in, e.g. pipelines/**/*.yaml. Can be repeated",
+						Destination: &migrationReq.Include,
+					},
+					&cli.StringSliceFlag{
+						Name:        "exclude",
+						Usage:       "glob `PATTERN` of the files & directories to skip, e.g. charts. Can be repeated",
+						Destination: &migrationReq.Exclude,
+					},
+					&cli.BoolFlag{
+						Name:        "no-gitignore",
+						Usage:       "if set will look for expressions in the files ignored by .gitignore as well",
+						Destination: &migrationReq.NoGitIgnore,
+					},
+					&cli.StringFlag{
+						Name:        "backup-suffix",
+						Usage:       "`SUFFIX` to add to the name of a copy of every file kept next to it before replacing, e.g. .orig. Copies are kept in .harness-upgrade otherwise",
+						Destination: &migrationReq.BackupSuffix,
+					},
+					&cli.StringFlag{
+						Name:        "expression-context",
+						Usage:       "`CONTEXT` the files are converted in. Can be one of workflow, pipeline, service or env. defaults to the one of the type of every file",
+						Destination: &migrationReq.ExpressionContext,
+					},
+					&cli.BoolFlag{
+						Name:        "interactive",
+						Usage:       "if set will ask whether to accept, edit or skip the replacement of every expression. Answers are remembered for the next runs",
+						Destination: &migrationReq.Interactive,
+					},
+					&cli.BoolFlag{
+						Name:        "lookup-secrets",
+						Usage:       "if set will look up the identifiers of the secrets referred to by expressions in next gen. Needs the api key & account",
+						Destination: &migrationReq.LookupSecrets,
+					},
 				},
+				Before: logToStderrForStdin,
 				Action: func(context *cli.Context) error {
 					return cliWrapper(ReplaceCurrentGenExpressionsWithNextGen, context)
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "revert",
+						Usage: "restores the files changed by the last replacement of expressions from their backups",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:        "force",
+								Usage:       "if set will revert files that were changed after the replacement as well",
+								Destination: &migrationReq.Force,
+							},
+						},
+						Action: func(context *cli.Context) error {
+							return cliWrapper(RevertExpressions, context)
+						},
+					},
+					{
+						Name:  "rules",
+						Usage: "lists the expressions & rules of the custom expressions file",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:        "validate",
+								Usage:       "if set will list the problems of the custom expressions file instead & fail if there are any",
+								Destination: &migrationReq.Validate,
+							},
+						},
+						Action: func(context *cli.Context) error {
+							return cliWrapper(ValidateExpressionRules, context)
+						},
+					},
+					{
+						Name:  "report",
+						Usage: "lists every expression found along with its next gen equivalent & prints how many can be converted automatically",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:        "format",
+								Usage:       "`FORMAT` of the report. Possible values - json, csv",
+								Value:       JsonReport,
+								DefaultText: JsonReport,
+								Destination: &migrationReq.ExpressionsReportFormat,
+							},
+							&cli.StringFlag{
+								Name:        "out",
+								Usage:       "`FILE` to write the report to. defaults to stdout",
+								Destination: &migrationReq.ExpressionsReportFile,
+							},
+						},
+						Before: logToStderrForReport,
+						Action: func(context *cli.Context) error {
+							return cliWrapper(ReportExpressions, context)
+						},
+					},
+					{
+						Name:  "lint",
+						Usage: "lists the next gen expressions that no first gen expression converts to & fails if there are any",
+						Action: func(context *cli.Context) error {
+							return cliWrapper(LintExpressions, context)
+						},
+					},
+				},
+			},
+			{
+				Name:  "overrides",
+				Usage: "Overrides file specific commands like generate & validate",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "generate",
+						Usage: "writes an overrides file with the current name & generated identifier of every entity. pass the --app flag for app level entities",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:        "types",
+								Usage:       "`TYPES` of the entities as `SERVICE,ENVIRONMENT,CONNECTOR`. defaults to all the types that support overrides",
+								Destination: &migrationReq.OverrideTypes,
+							},
+							&cli.StringFlag{
+								Name:        "out",
+								Usage:       "`FILE` to write the overrides to",
+								Value:       "overrides.yaml",
+								DefaultText: "overrides.yaml",
+								Destination: &migrationReq.OverridesOut,
+							},
+							&cli.BoolFlag{
+								Name:        "force",
+								Usage:       "if set will overwrite the file if it exists",
+								Destination: &migrationReq.Force,
+							},
+						},
+						Action: func(context *cli.Context) error {
+							return cliWrapper(GenerateOverrides, context)
+						},
+					},
+					{
+						Name:  "validate",
+						Usage: "lists all the problems of the overrides file given by --override & fails if there are any",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:        "offline",
+								Usage:       "if set will only check the file without looking up the entities in first & next gen",
+								Destination: &migrationReq.Offline,
+							},
+						},
+						Action: func(context *cli.Context) error {
+							return cliWrapper(ValidateOverrides, context)
+						},
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Config file specific commands to manage named contexts like use-context, get-contexts & set",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "use-context",
+						Usage:     "makes the context the one used when --context is not passed",
+						ArgsUsage: "NAME",
+						Action: func(context *cli.Context) error {
+							return cliWrapper(UseConfigContext, context)
+						},
+					},
+					{
+						Name:  "get-contexts",
+						Usage: "lists the contexts in the config file",
+						Action: func(context *cli.Context) error {
+							return cliWrapper(GetConfigContexts, context)
+						},
+					},
+					{
+						Name:      "set",
+						Usage:     "sets a global flag in the context given by --context or else the current context",
+						ArgsUsage: "KEY VALUE",
+						Action: func(context *cli.Context) error {
+							return cliWrapper(SetConfigValue, context)
+						},
+					},
+				},
 			},
 			{
 				Name:  "project",
@@ -572,6 +1012,13 @@ func main() {
 						Usage:       "`NAMES` of the projects",
 						Destination: &migrationReq.Names,
 					},
+					&cli.IntFlag{
+						Name:        "concurrency",
+						Usage:       "`N` projects that are removed at the same time",
+						Value:       1,
+						DefaultText: "1",
+						Destination: &migrationReq.Concurrency,
+					},
 				},
 				Subcommands: []*cli.Command{
 					{
@@ -628,6 +1075,13 @@ func main() {
 						Usage:       "`NAMES` of the org",
 						Destination: &migrationReq.Names,
 					},
+					&cli.IntFlag{
+						Name:        "concurrency",
+						Usage:       "`N` orgs that are removed at the same time",
+						Value:       1,
+						DefaultText: "1",
+						Destination: &migrationReq.Concurrency,
+					},
 				},
 				Subcommands: []*cli.Command{
 					{
@@ -665,6 +1119,13 @@ func main() {
 						Usage:       "`NAMES` of the template",
 						Destination: &migrationReq.Names,
 					},
+					&cli.IntFlag{
+						Name:        "concurrency",
+						Usage:       "`N` templates that are removed at the same time",
+						Value:       1,
+						DefaultText: "1",
+						Destination: &migrationReq.Concurrency,
+					},
 					&cli.BoolFlag{
 						Name:        "force",
 						Usage:       "to force delete template",
@@ -694,10 +1155,16 @@ func main() {
 				},
 			},
 		},
-		Before: altsrc.InitInputSourceWithContext(globalFlags, altsrc.NewYamlSourceFromFlagFunc("load")),
-		Flags:  globalFlags,
+		Before: func(context *cli.Context) error {
+			if err := altsrc.InitInputSourceWithContext(globalFlags, altsrc.NewYamlSourceFromFlagFunc("load"))(context); err != nil {
+				return err
+			}
+			return applyConfigContext(context)
+		},
+		Flags: globalFlags,
 	}
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		os.Exit(exitCodeFor(err))
 	}
 }
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Contexts that expressions are converted in
+const (
+	WorkflowContext    = "workflow"
+	PipelineContext    = "pipeline"
+	ServiceContext     = "service"
+	EnvironmentContext = "env"
+)
+
+var expressionContexts = []string{WorkflowContext, PipelineContext, ServiceContext, EnvironmentContext}
+
+// ContextExpressions override the ExpressionsMap within a context
+var ContextExpressions = map[string]map[string]string{
+	PipelineContext: {
+		"workflow.name":        "<+pipeline.name>",
+		"workflow.description": "<+pipeline.description>",
+	},
+}
+
+// ContextDynamicExpressions override the DynamicExpressions within a context
+var ContextDynamicExpressions = map[string]map[string]interface{}{
+	PipelineContext: {
+		"workflow.variables": func(key string) string {
+			return "<+pipeline.variables." + key + ">"
+		},
+	},
+}
+
+// ExpressionRule maps expressions differently in the files, YAML key paths or context it is scoped to. Rules with
+// more than one scope apply only where all of them match.
+type ExpressionRule struct {
+	Context string `yaml:"context"`
+	// Files are glob patterns of the files the rule applies to, e.g. pipelines/**/*.yaml
+	Files []string `yaml:"files"`
+	// KeyPaths are glob patterns of the YAML keys the rule applies to, e.g. pipeline.stages.**.script
+	KeyPaths    []string          `yaml:"keyPaths"`
+	Expressions map[string]string `yaml:"expressions"`
+	Rules       []MappingRule     `yaml:"rules"`
+
+	line int
+}
+
+func (r *ExpressionRule) UnmarshalYAML(node *yaml.Node) error {
+	type plain ExpressionRule
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.line = node.Line
+	return nil
+}
+
+// CustomExpressions is the content of the custom expressions file. The file is either a map of expressions or has
+// expressions & rules that apply everywhere & scoped rules in separate sections.
+type CustomExpressions struct {
+	Expressions map[string]string `yaml:"expressions"`
+	Rules       []MappingRule     `yaml:"rules"`
+	Scopes      []ExpressionRule  `yaml:"scopes"`
+}
+
+// ExpressionRules are the scoped rules loaded from the custom expressions file. Later rules take precedence.
+var ExpressionRules []ExpressionRule
+
+// CustomExpressionsMap are the expressions of the custom expressions file that apply everywhere. They are kept apart
+// from the ExpressionsMap so that they take precedence over the expressions of a context.
+var CustomExpressionsMap = make(map[string]string)
+
+// CustomRules are the exact, prefix & regex rules of the custom expressions file that apply everywhere
+var CustomRules []MappingRule
+
+// ExpressionScope is where an expression is found
+type ExpressionScope struct {
+	// Path of the file with forward slashes
+	Path    string
+	Context string
+	KeyPath string
+	// Steps of the First Gen workflow the expression is found in
+	Steps WorkflowSteps
+}
+
+// lookup returns the Next Gen equivalent of the expression in the scope. Scoped rules take precedence over the custom
+// expressions, then the custom rules, then the expressions of the context & then the ExpressionsMap.
+func (s ExpressionScope) lookup(key string) (string, bool) {
+	for i := len(ExpressionRules) - 1; i >= 0; i-- {
+		rule := ExpressionRules[i]
+		if !rule.matches(s) {
+			continue
+		}
+		if val, ok := rule.Expressions[key]; ok {
+			return val, true
+		}
+		if val, ok := applyRules(rule.Rules, key); ok {
+			return val, true
+		}
+	}
+	if val, ok := CustomExpressionsMap[key]; ok {
+		return val, true
+	}
+	if val, ok := applyRules(CustomRules, key); ok {
+		return val, true
+	}
+	if val, ok := ContextExpressions[s.Context][key]; ok {
+		return val, true
+	}
+	val, ok := ExpressionsMap[key]
+	return val, ok
+}
+
+// dynamicExpressions returns the DynamicExpressions with the ones of the context applied
+func (s ExpressionScope) dynamicExpressions() map[string]interface{} {
+	overrides := ContextDynamicExpressions[s.Context]
+	if len(overrides) == 0 {
+		return DynamicExpressions
+	}
+	dynamic := make(map[string]interface{}, len(DynamicExpressions))
+	for k, v := range DynamicExpressions {
+		dynamic[k] = v
+	}
+	for k, v := range overrides {
+		dynamic[k] = v
+	}
+	return dynamic
+}
+
+func (r ExpressionRule) matches(s ExpressionScope) bool {
+	if len(r.Context) > 0 && r.Context != s.Context {
+		return false
+	}
+	if len(r.Files) > 0 && (len(s.Path) == 0 || !matchesAny(r.Files, s.Path)) {
+		return false
+	}
+	if len(r.KeyPaths) > 0 && (len(s.KeyPath) == 0 || !matchesAnyKeyPath(r.KeyPaths, s.KeyPath)) {
+		return false
+	}
+	return true
+}
+
+func matchesAnyKeyPath(patterns []string, keyPath string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(strings.ReplaceAll(pattern, ".", "/"), strings.ReplaceAll(keyPath, ".", "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadCustomExpressionsFromFile reads the custom expressions file in either of its formats
+func LoadCustomExpressionsFromFile(filePath string) (CustomExpressions, error) {
+	custom, problems, err := readCustomExpressions(filePath)
+	if err != nil {
+		return custom, err
+	}
+	if len(problems) > 0 {
+		return custom, &ValidationError{Message: fmt.Sprintf("invalid custom expressions file %s - %s", filePath, strings.Join(problems, "; "))}
+	}
+	if len(filePath) > 0 {
+		log.Infof("Successfully loaded %d custom expressions, %d rules & %d scopes from the file", len(custom.Expressions), len(custom.Rules), len(custom.Scopes))
+	}
+	return custom, nil
+}
+
+// readCustomExpressions reads the custom expressions file & returns the problems found in it. Its rules are compiled
+// if they have no problems.
+func readCustomExpressions(filePath string) (CustomExpressions, []string, error) {
+	var custom CustomExpressions
+	filePath = strings.TrimSpace(filePath)
+	if len(filePath) == 0 {
+		return custom, nil, nil
+	}
+	yFile, err := os.ReadFile(filePath)
+	if err != nil {
+		return custom, nil, err
+	}
+	var root yaml.Node
+	if err = yaml.Unmarshal(yFile, &root); err == nil {
+		if isStructuredExpressionsFile(&root) {
+			err = root.Decode(&custom)
+		} else {
+			err = root.Decode(&custom.Expressions)
+		}
+	}
+	if err != nil {
+		return custom, nil, &ValidationError{Message: fmt.Sprintf("invalid custom expressions file %s - %s", filePath, err)}
+	}
+	return custom, lintCustomExpressions(&custom), nil
+}
+
+func isValidGlob(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+// isStructuredExpressionsFile reports if the file has the expressions, rules & scopes sections instead of being a map
+// of expressions
+func isStructuredExpressionsFile(root *yaml.Node) bool {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+	content := root.Content[0].Content
+	for i := 0; i+1 < len(content); i += 2 {
+		if slices.Contains([]string{"expressions", "rules", "scopes"}, content[i].Value) && content[i+1].Kind != yaml.ScalarNode {
+			return true
+		}
+	}
+	return false
+}
+
+// Types of First Gen YAML files that are converted in a context
+var contextsOfTypes = map[string]string{
+	"PIPELINE":      PipelineContext,
+	"SERVICE":       ServiceContext,
+	"ENVIRONMENT":   EnvironmentContext,
+	"BASIC":         WorkflowContext,
+	"CANARY":        WorkflowContext,
+	"BLUE_GREEN":    WorkflowContext,
+	"ROLLING":       WorkflowContext,
+	"MULTI_SERVICE": WorkflowContext,
+	"BUILD":         WorkflowContext,
+	"CUSTOM":        WorkflowContext,
+}
+
+// Root keys of Next Gen YAML files that are converted in a context
+var contextsOfRootKeys = map[string]string{
+	"pipeline":                 PipelineContext,
+	"service":                  ServiceContext,
+	"environment":              EnvironmentContext,
+	"infrastructureDefinition": EnvironmentContext,
+}
+
+// yamlValue is a value of a YAML document along with the key path to it
+type yamlValue struct {
+	line    int
+	column  int
+	keyPath string
+}
+
+// WorkflowStep is a step of a First Gen workflow
+type WorkflowStep struct {
+	Name string
+	Type string
+	// OutputName is the name a shell script step publishes its output variables under
+	OutputName string
+}
+
+type WorkflowSteps []WorkflowStep
+
+// Keys of First Gen workflows that hold steps
+var workflowStepKeys = []string{"steps", "preDeploymentSteps", "postDeploymentSteps"}
+
+// yamlDocument holds what the expressions of a file need to be converted in the right scope
+type yamlDocument struct {
+	context string
+	values  []yamlValue
+	steps   WorkflowSteps
+}
+
+// parseYamlDocument parses the content if it is YAML or JSON. The context is --expression-context if set or else the
+// one of the type or root key of the document.
+func parseYamlDocument(content string) yamlDocument {
+	doc := yamlDocument{context: migrationReq.ExpressionContext}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+		return doc
+	}
+	node := root.Content[0]
+	if node.Kind != yaml.MappingNode {
+		return doc
+	}
+	if len(doc.context) == 0 {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if key == "type" && value.Kind == yaml.ScalarNode && len(contextsOfTypes[value.Value]) > 0 {
+				doc.context = contextsOfTypes[value.Value]
+			}
+			if ctx, ok := contextsOfRootKeys[key]; ok {
+				doc.context = ctx
+			}
+		}
+	}
+	doc.values = collectYamlValues(node, "", doc.values)
+	doc.steps = collectWorkflowSteps(node, doc.steps)
+	sort.SliceStable(doc.values, func(i, j int) bool {
+		a, b := doc.values[i], doc.values[j]
+		return a.line < b.line || (a.line == b.line && a.column < b.column)
+	})
+	return doc
+}
+
+func collectYamlValues(node *yaml.Node, keyPath string, values []yamlValue) []yamlValue {
+	join := func(key string) string {
+		if len(keyPath) == 0 {
+			return key
+		}
+		return keyPath + "." + key
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			values = collectYamlValues(node.Content[i+1], join(node.Content[i].Value), values)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			values = collectYamlValues(item, join(strconv.Itoa(i)), values)
+		}
+	case yaml.ScalarNode:
+		values = append(values, yamlValue{line: node.Line, column: node.Column, keyPath: keyPath})
+	}
+	return values
+}
+
+// collectWorkflowSteps finds the steps of a First Gen workflow, which are the items of the phase steps & of the pre &
+// post deployment steps
+func collectWorkflowSteps(node *yaml.Node, steps WorkflowSteps) WorkflowSteps {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if slices.Contains(workflowStepKeys, key) && value.Kind == yaml.SequenceNode {
+				for _, item := range value.Content {
+					if step, ok := parseWorkflowStep(item); ok {
+						steps = append(steps, step)
+					}
+				}
+			}
+			steps = collectWorkflowSteps(value, steps)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			steps = collectWorkflowSteps(item, steps)
+		}
+	}
+	return steps
+}
+
+func parseWorkflowStep(node *yaml.Node) (WorkflowStep, bool) {
+	var step struct {
+		Name       string `yaml:"name"`
+		Type       string `yaml:"type"`
+		Properties struct {
+			SweepingOutputName string `yaml:"sweepingOutputName"`
+		} `yaml:"properties"`
+	}
+	if node.Kind != yaml.MappingNode || node.Decode(&step) != nil || len(step.Name) == 0 || len(step.Type) == 0 {
+		return WorkflowStep{}, false
+	}
+	return WorkflowStep{Name: step.Name, Type: step.Type, OutputName: step.Properties.SweepingOutputName}, true
+}
+
+// only returns the Next Gen identifier of the step that matches. Nothing is returned if no step or steps with different
+// identifiers match, as the one the expression refers to cannot be known.
+func (s WorkflowSteps) only(match func(step WorkflowStep) bool) (string, bool) {
+	var identifier string
+	for _, step := range s {
+		if !match(step) {
+			continue
+		}
+		if id := ToIdentifier(step.Name); len(identifier) == 0 {
+			identifier = id
+		} else if id != identifier {
+			return "", false
+		}
+	}
+	return identifier, len(identifier) > 0
+}
+
+// ofType returns the Next Gen identifier of the only step of the types
+func (s WorkflowSteps) ofType(types ...string) (string, bool) {
+	return s.only(func(step WorkflowStep) bool {
+		return slices.Contains(types, step.Type)
+	})
+}
+
+// keyPathAt returns the key path of the value the position is in, which is the last value that starts before it
+func (d yamlDocument) keyPathAt(pos Position) string {
+	i := sort.Search(len(d.values), func(i int) bool {
+		v := d.values[i]
+		return v.line > pos.Line || (v.line == pos.Line && v.column > pos.Column)
+	})
+	if i == 0 {
+		return ""
+	}
+	return d.values[i-1].keyPath
+}
+
+// scopeOf returns the scope of an expression found in the file at the path
+func (d yamlDocument) scopeOf(filePath string, e *Expression) ExpressionScope {
+	if len(filePath) > 0 {
+		filePath = filepath.ToSlash(filepath.Clean(filePath))
+	}
+	return ExpressionScope{Path: filePath, Context: d.context, KeyPath: d.keyPathAt(e.Start), Steps: d.steps}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
@@ -8,15 +9,24 @@ import (
 	"strconv"
 )
 
-func createOrg(*cli.Context) error {
-	promptConfirm := PromptEnvDetails()
+func createOrg(ctx *cli.Context) error {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.OrgName) == 0 {
 		promptConfirm = true
-		migrationReq.OrgName = TextInput("Name of the Org - ")
+		migrationReq.OrgName, err = TextInput("Name of the Org - ")
+		if err != nil {
+			return err
+		}
 	}
 	if len(migrationReq.OrgIdentifier) == 0 {
 		promptConfirm = true
-		migrationReq.OrgIdentifier = TextInput("Identifier for the Org - ")
+		migrationReq.OrgIdentifier, err = TextInput("Identifier for the Org - ")
+		if err != nil {
+			return err
+		}
 	}
 
 	log.WithFields(log.Fields{
@@ -28,15 +38,19 @@ func createOrg(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with org creation?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
-	url := fmt.Sprintf("%s/api/organizations?accountIdentifier=%s", GetBaseUrl(migrationReq.Environment, NextGenService), migrationReq.Account)
+	baseUrl, err := GetBaseUrl(migrationReq.Environment, NextGenService)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/organizations?accountIdentifier=%s", baseUrl, migrationReq.Account)
 
 	log.Info("Creating the org....")
 
-	_, err := Post(url, migrationReq.Auth, OrgBody{
+	_, err = Post(ctx.Context, url, migrationReq.Auth, OrgBody{
 		Org: OrgDetails{
 			Identifier:  migrationReq.OrgIdentifier,
 			Name:        migrationReq.OrgName,
@@ -53,15 +67,18 @@ func createOrg(*cli.Context) error {
 	return nil
 }
 
-func bulkRemoveOrg(*cli.Context) error {
-	promptConfirm := PromptEnvDetails()
+func bulkRemoveOrg(ctx *cli.Context) error {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
 	names := Split(migrationReq.Names, ",")
 	identifiers := Split(migrationReq.Identifiers, ",")
 	if len(names) == 0 && len(identifiers) == 0 {
-		log.Fatal("No names or identifiers for the organisations provided. Aborting")
+		return &ValidationError{Message: "No names or identifiers for the organisations provided. Aborting"}
 	}
 	if len(names) > 0 && len(identifiers) > 0 {
-		log.Fatal("Both names and identifiers for the organisations provided. Aborting")
+		return &ValidationError{Message: "Both names and identifiers for the organisations provided. Aborting"}
 	}
 
 	n := len(identifiers)
@@ -71,12 +88,15 @@ func bulkRemoveOrg(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Are you sure you want to proceed with deletion of " + strconv.Itoa(n) + " organisations?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	if len(names) > 0 {
-		organisations := getOrganisations()
+		organisations, err := getOrganisations(ctx.Context)
+		if err != nil {
+			return err
+		}
 		for _, name := range names {
 			id := findOrgIdByName(organisations, name)
 			if len(id) > 0 {
@@ -86,48 +106,60 @@ func bulkRemoveOrg(*cli.Context) error {
 		log.Debugf("Valid identifiers for the given names are - %s", identifiers)
 	}
 
-	for _, identifier := range identifiers {
-		deleteOrg(identifier)
-	}
+	forEachConcurrently(ctx.Context, identifiers, migrationReq.Concurrency, func(identifier string) {
+		deleteOrg(ctx.Context, identifier)
+	})
 	log.Info("Finished operation for all given organisations")
 	return nil
 }
 
-func deleteOrg(orgId string) {
-	url := fmt.Sprintf("%s/api/organizations/%s?accountIdentifier=%s", GetBaseUrl(migrationReq.Environment, NextGenService), orgId, migrationReq.Account)
+func deleteOrg(ctx context.Context, orgId string) {
+	baseUrl, err := GetBaseUrl(migrationReq.Environment, NextGenService)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to delete the org - %s", orgId)
+		return
+	}
+	url := fmt.Sprintf("%s/api/organizations/%s?accountIdentifier=%s", baseUrl, orgId, migrationReq.Account)
 
 	log.Infof("Deleting the org with identifier %s", orgId)
 
-	_, err := Delete(url, migrationReq.Auth, nil)
+	_, err = Delete(ctx, url, migrationReq.Auth, nil)
 
 	if err == nil {
 		log.Infof("Successfully deleted the org - %s", orgId)
 	} else {
-		log.Errorf("Failed to delete the org - %s", orgId)
+		log.WithError(err).Errorf("Failed to delete the org - %s", orgId)
 	}
 }
 
-func getOrganisations() []OrgDetails {
-	url := fmt.Sprintf("%s/api/aggregate/organizations?accountIdentifier=%s&pageSize=1000", GetBaseUrl(migrationReq.Environment, NextGenService), migrationReq.Account)
-	resp, err := Get(url, migrationReq.Auth)
-	if err != nil || resp.Status != "SUCCESS" {
-		log.Fatal("Failed to fetch organisations", err)
+func getOrganisations(ctx context.Context) ([]OrgDetails, error) {
+	baseUrl, err := GetBaseUrl(migrationReq.Environment, NextGenService)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/aggregate/organizations?accountIdentifier=%s&pageSize=1000", baseUrl, migrationReq.Account)
+	resp, err := Get(ctx, url, migrationReq.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organisations: %w", err)
+	}
+	if resp.Status != "SUCCESS" {
+		return nil, &APIError{StatusCode: 200, Url: url, Message: resp.Message, Messages: resp.Messages}
 	}
 	byteData, err := json.Marshal(resp.Data)
 	if err != nil {
-		log.Fatal("Failed to fetch organisations", err)
+		return nil, fmt.Errorf("failed to fetch organisations: %w", err)
 	}
 	var orgListBody OrgListBody
 	err = json.Unmarshal(byteData, &orgListBody)
 	if err != nil {
-		log.Fatal("Failed to fetch organisations", err)
+		return nil, fmt.Errorf("failed to fetch organisations: %w", err)
 	}
 	var details []OrgDetails
 
 	for _, o := range orgListBody.Organisations {
 		details = append(details, o.Org.Org)
 	}
-	return details
+	return details, nil
 }
 
 func findOrgIdByName(organisations []OrgDetails, orgName string) string {
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// OverrideRule overrides all the entities that match it instead of a single one. An entity matches if it has the
+// type, belongs to the app & has a name that matches the regex, ignoring the matchers that are not set. Name &
+// identifier are templates of the OverrideRuleData.
+type OverrideRule struct {
+	Type       string  `json:"type" yaml:"type"`
+	AppId      string  `json:"appId" yaml:"appId"`
+	Match      string  `json:"match" yaml:"match"`
+	Name       *string `json:"name" yaml:"name"`
+	Identifier *string `json:"identifier" yaml:"identifier"`
+	Scope      *string `json:"scope" yaml:"scope"`
+
+	line       int
+	re         *regexp.Regexp
+	name       *template.Template
+	identifier *template.Template
+}
+
+// OverrideRuleData is what the templates of a rule are executed with
+type OverrideRuleData struct {
+	Id   string
+	Type string
+	Name string
+	// Identifier is the identifier that would be generated for the entity
+	Identifier string
+	// Match holds the name & the capture groups of the regex
+	Match []string
+}
+
+var overrideTemplateFuncs = template.FuncMap{
+	"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"identifier": ToIdentifier,
+}
+
+func (r *OverrideRule) UnmarshalYAML(node *yaml.Node) error {
+	type plain OverrideRule
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.line = node.Line
+	return nil
+}
+
+// lint returns the problems of the rule. The regex & templates of the rule are compiled if it has none.
+func (r *OverrideRule) lint() []string {
+	var problems []string
+	if len(r.Type) > 0 && !slices.Contains(overrideTypes, r.Type) {
+		problems = append(problems, fmt.Sprintf("line %d: invalid type %s. Allowed values are %s", r.line, r.Type, strings.Join(overrideTypes, ", ")))
+	}
+	if len(r.AppId) > 0 && len(r.Type) > 0 && !slices.Contains(appOverrideTypes, r.Type) {
+		problems = append(problems, fmt.Sprintf("line %d: appId cannot be used with the type %s. It only applies to %s", r.line, r.Type, strings.Join(appOverrideTypes, ", ")))
+	}
+	if len(r.Type) == 0 && len(r.AppId) == 0 && len(r.Match) == 0 {
+		problems = append(problems, fmt.Sprintf("line %d: the rule needs at least one of type, appId or match", r.line))
+	}
+	if r.Name == nil && r.Identifier == nil && r.Scope == nil {
+		problems = append(problems, fmt.Sprintf("line %d: the name, identifier & scope are all blank", r.line))
+	}
+	if r.Scope != nil && !slices.Contains(scopes, *r.Scope) {
+		problems = append(problems, fmt.Sprintf("line %d: invalid scope %s. Allowed values are %s", r.line, *r.Scope, strings.Join(scopes, ", ")))
+	}
+	var err error
+	if len(r.Match) > 0 {
+		if r.re, err = regexp.Compile(r.Match); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid regex %s - %s", r.line, r.Match, err))
+		}
+	}
+	if r.Name != nil {
+		if r.name, err = template.New("name").Funcs(overrideTemplateFuncs).Option("missingkey=error").Parse(*r.Name); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid name template - %s", r.line, err))
+		}
+	}
+	if r.Identifier != nil {
+		if r.identifier, err = template.New("identifier").Funcs(overrideTemplateFuncs).Option("missingkey=error").Parse(*r.Identifier); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid identifier template - %s", r.line, err))
+		}
+	}
+	return problems
+}
+
+func lintOverrideRules(rules []OverrideRule) []string {
+	var problems []string
+	for i := range rules {
+		problems = append(problems, rules[i].lint()...)
+	}
+	return problems
+}
+
+// matches returns the name & the capture groups of the regex if the entity matches the rule
+func (r *OverrideRule) matches(entityType string, entity BaseEntityDetail) ([]string, bool) {
+	if !r.appliesTo(entityType) {
+		return nil, false
+	}
+	if len(r.AppId) > 0 && r.AppId != migrationReq.AppId {
+		return nil, false
+	}
+	if len(r.Match) > 0 && r.re == nil {
+		return nil, false
+	}
+	if r.re == nil {
+		return []string{entity.Name}, true
+	}
+	match := r.re.FindStringSubmatch(entity.Name)
+	return match, match != nil
+}
+
+// appliesTo tells if the rule can match the entities of the type. Rules with an appId only match app level entities.
+func (r *OverrideRule) appliesTo(entityType string) bool {
+	if len(r.Type) > 0 && r.Type != entityType {
+		return false
+	}
+	return len(r.AppId) == 0 || slices.Contains(appOverrideTypes, entityType)
+}
+
+// apply returns the override of the entity. Blank names & identifiers are not overridden.
+func (r *OverrideRule) apply(entityType string, entity BaseEntityDetail, match []string) (EntityOverride, error) {
+	override := EntityOverride{ID: entity.Id, FirstGenName: entity.Name, Type: entityType, Scope: r.Scope, line: r.line}
+	data := OverrideRuleData{Id: entity.Id, Type: entityType, Name: entity.Name, Identifier: ToIdentifier(entity.Name), Match: match}
+	render := func(t *template.Template) (*string, error) {
+		if t == nil {
+			return nil, nil
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, &ValidationError{Message: fmt.Sprintf("line %d: failed to render the %s of the %s named %s - %s", r.line, t.Name(), entityType, entity.Name, err)}
+		}
+		value := strings.TrimSpace(buf.String())
+		if len(value) == 0 {
+			return nil, nil
+		}
+		return &value, nil
+	}
+	var err error
+	if override.Name, err = render(r.name); err != nil {
+		return override, err
+	}
+	if override.Identifier, err = render(r.identifier); err != nil {
+		return override, err
+	}
+	if override.Identifier != nil && !nextGenIdentifierPattern.MatchString(*override.Identifier) {
+		return override, &ValidationError{Message: fmt.Sprintf("line %d: the identifier %s of the %s named %s is invalid", r.line, *override.Identifier, entityType, entity.Name)}
+	}
+	return override, nil
+}
+
+// applyOverrideRules returns the override of the first rule the entity matches
+func applyOverrideRules(rules []OverrideRule, entityType string, entity BaseEntityDetail) (EntityOverride, bool, error) {
+	for i := range rules {
+		if match, ok := rules[i].matches(entityType, entity); ok {
+			override, err := rules[i].apply(entityType, entity, match)
+			return override, true, err
+		}
+	}
+	return EntityOverride{}, false, nil
+}
+
+// overrideRuleTypes returns the types of the entities the rules can match. App level entities are only matched when
+// an app is given.
+func overrideRuleTypes(rules []OverrideRule) []string {
+	var types []string
+	for _, entityType := range overrideTypes {
+		if slices.Contains(appOverrideTypes, entityType) && len(migrationReq.AppId) == 0 {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.appliesTo(entityType) {
+				types = append(types, entityType)
+				break
+			}
+		}
+	}
+	return types
+}
+
+// expandOverrideRules adds the overrides of the entities that match the rules & are not overridden already
+func expandOverrideRules(ctx context.Context, rules []OverrideRule, overrides map[string]EntityOverrideInput, entities entityCache) error {
+	expanded := 0
+	for _, entityType := range overrideRuleTypes(rules) {
+		items, err := entities.list(ctx, entityType)
+		if err != nil {
+			return err
+		}
+		for _, entity := range items {
+			key := fmt.Sprintf("CgEntityId(id=%s, type=%s)", entity.Id, entityType)
+			if _, ok := overrides[key]; ok {
+				continue
+			}
+			override, ok, err := applyOverrideRules(rules, entityType, entity)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			overrides[key] = EntityOverrideInput{
+				Name:       override.Name,
+				Identifier: override.Identifier,
+				Scope:      override.Scope,
+			}
+			expanded++
+		}
+	}
+	log.Infof("Expanded %d override rules into %d overrides", len(rules), expanded)
+	return nil
+}
+
+// entityCache lists the First Gen entities of every type once
+type entityCache map[string][]BaseEntityDetail
+
+func (c entityCache) list(ctx context.Context, entityType string) ([]BaseEntityDetail, error) {
+	if entities, ok := c[entityType]; ok {
+		return entities, nil
+	}
+	entities, err := listEntities(ctx, GetEndpointFromType(entityType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the entities of type %s: %w", entityType, err)
+	}
+	c[entityType] = entities
+	return entities, nil
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// overrideTypes are the types of entities that support overrides
+var overrideTypes = []string{UserGroups, Template, Connector, Secret, Service, Environment, Workflow, Pipeline}
+
+// appOverrideTypes are the types of entities that belong to an application
+var appOverrideTypes = []string{Service, Environment, Workflow, Pipeline}
+
+const overridesFileHeader = `# Generated by harness-upgrade. Every entity keeps its current name & the identifier that would be generated for it.
+# Edit the entries you want to change & remove the others. A blank scope uses the scope given by the flags.
+`
+
+// GenerateOverrides writes an overrides file with an entry for every entity of the given types
+func GenerateOverrides(ctx *cli.Context) error {
+	types := Split(strings.ToUpper(migrationReq.OverrideTypes), ",")
+	if len(types) == 0 {
+		types = overrideTypes
+	}
+	for _, entityType := range types {
+		if err := assertAllowedValues(entityType, overrideTypes, fmt.Sprintf("Invalid type %s. Possible values - %s", entityType, strings.Join(overrideTypes, ", "))); err != nil {
+			return err
+		}
+	}
+	if len(migrationReq.AppId) == 0 && ContainsAny(types, appOverrideTypes) {
+		return &ValidationError{Message: fmt.Sprintf("Overrides of %s need an app. Use --app to provide one", strings.Join(appOverrideTypes, ", "))}
+	}
+	if _, err := os.Stat(migrationReq.OverridesOut); err == nil && !migrationReq.Force {
+		return &ValidationError{Message: fmt.Sprintf("%s already exists. Use --out to write to another file or --force to overwrite it", migrationReq.OverridesOut)}
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if _, err := PromptEnvDetails(); err != nil {
+		return err
+	}
+
+	var data OverrideFileData
+	for _, entityType := range types {
+		entities, err := listEntities(ctx.Context, GetEndpointFromType(entityType))
+		if err != nil {
+			return fmt.Errorf("failed to list the entities of type %s: %w", entityType, err)
+		}
+		sort.SliceStable(entities, func(i, j int) bool {
+			return strings.ToLower(entities[i].Name) < strings.ToLower(entities[j].Name)
+		})
+		for _, entity := range entities {
+			data.Overrides = append(data.Overrides, newEntityOverride(entityType, entity))
+		}
+		log.Infof("Found %d entities of type %s", len(entities), entityType)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(overridesFileHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("failed to create the overrides: %w", err)
+	}
+	if err := WriteFileAtomic(migrationReq.OverridesOut, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the overrides: %w", err)
+	}
+	log.Infof("Wrote %d overrides to %s. Pass it with --override once edited", len(data.Overrides), migrationReq.OverridesOut)
+	return nil
+}
+
+// newEntityOverride returns the override of an entity that keeps its current name & generated identifier
+func newEntityOverride(entityType string, entity BaseEntityDetail) EntityOverride {
+	name := entity.Name
+	identifier := ToIdentifier(entity.Name)
+	scope := ""
+	return EntityOverride{
+		ID:           entity.Id,
+		FirstGenName: entity.Name,
+		Type:         entityType,
+		Identifier:   &identifier,
+		Name:         &name,
+		Scope:        &scope,
+	}
+}
+
+// normalizeOverride drops the blank fields of an override so that they are not sent as overrides
+func normalizeOverride(override *EntityOverride) {
+	dropBlankFields(&override.Name, &override.Identifier, &override.Scope)
+}
+
+func dropBlankFields(fields ...**string) {
+	for _, field := range fields {
+		if *field != nil && len(strings.TrimSpace(**field)) == 0 {
+			*field = nil
+		}
+	}
+}
+
+// nextGenIdentifierPattern matches the identifiers of entities in Next Gen
+var nextGenIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][0-9a-zA-Z_$]{0,127}$`)
+
+func (o *EntityOverride) UnmarshalYAML(node *yaml.Node) error {
+	type plain EntityOverride
+	if err := node.Decode((*plain)(o)); err != nil {
+		return err
+	}
+	o.line = node.Line
+	return nil
+}
+
+// readOverrides reads the overrides file & returns the problems found in it. Blank fields of the overrides are dropped.
+func readOverrides(filePath string) (OverrideFileData, []string, error) {
+	var data OverrideFileData
+	yFile, err := os.ReadFile(filePath)
+	if err != nil {
+		return data, nil, err
+	}
+	if err = yaml.Unmarshal(yFile, &data); err != nil {
+		return data, nil, &ValidationError{Message: fmt.Sprintf("invalid overrides file %s - %s", filePath, err)}
+	}
+	for i := range data.Overrides {
+		normalizeOverride(&data.Overrides[i])
+	}
+	for i := range data.Rules {
+		rule := &data.Rules[i]
+		dropBlankFields(&rule.Name, &rule.Identifier, &rule.Scope)
+	}
+	return data, append(lintOverrides(data.Overrides), lintOverrideRules(data.Rules)...), nil
+}
+
+// lintOverrides returns all the problems of the overrides that can be found without looking up the entities
+func lintOverrides(overrides []EntityOverride) []string {
+	var problems []string
+	seen := make(map[string]int)
+	for _, override := range overrides {
+		line := override.line
+		if len(strings.TrimSpace(override.Type)) == 0 {
+			problems = append(problems, fmt.Sprintf("line %d: the type is blank", line))
+		} else if !slices.Contains(overrideTypes, override.Type) {
+			problems = append(problems, fmt.Sprintf("line %d: invalid type %s. Allowed values are %s", line, override.Type, strings.Join(overrideTypes, ", ")))
+		}
+		if override.Name == nil && override.Identifier == nil && override.Scope == nil {
+			problems = append(problems, fmt.Sprintf("line %d: the name, identifier & scope are all blank", line))
+		}
+		if override.Identifier != nil && !nextGenIdentifierPattern.MatchString(*override.Identifier) {
+			problems = append(problems, fmt.Sprintf("line %d: invalid identifier %s. Identifiers start with a letter or _ & have up to 128 letters, digits, _ or $", line, *override.Identifier))
+		}
+		if override.Scope != nil && !slices.Contains(scopes, *override.Scope) {
+			problems = append(problems, fmt.Sprintf("line %d: invalid scope %s. Allowed values are %s", line, *override.Scope, strings.Join(scopes, ", ")))
+		}
+
+		var key string
+		switch {
+		case len(strings.TrimSpace(override.ID)) > 0:
+			key = override.Type + " with id " + override.ID
+		case len(strings.TrimSpace(override.FirstGenName)) > 0:
+			key = override.Type + " named " + override.FirstGenName
+		default:
+			problems = append(problems, fmt.Sprintf("line %d: both the id & the firstGenName are blank", line))
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: the %s is overridden on line %d as well", line, key, first))
+			continue
+		}
+		seen[key] = line
+	}
+	return problems
+}
+
+// overrideTarget is where an entity ends up in Next Gen
+type overrideTarget struct {
+	entityType string
+	scope      string
+	identifier string
+}
+
+// overrideOwner is what a Next Gen identifier is taken by. The line is 0 for generated identifiers.
+type overrideOwner struct {
+	line        int
+	description string
+	// name of the entity in Next Gen
+	name string
+}
+
+// ValidateOverrides reports all the problems of the overrides file at once. Unless --offline is set, the entities are
+// looked up to find the identifiers that collide with generated ones or with the entities that exist in Next Gen.
+func ValidateOverrides(ctx *cli.Context) error {
+	filePath := strings.TrimSpace(migrationReq.OverrideFile)
+	if len(filePath) == 0 {
+		return &ValidationError{Message: "No overrides file provided. Use --override to provide one"}
+	}
+	data, problems, err := readOverrides(filePath)
+	if err != nil {
+		return err
+	}
+	if !migrationReq.Offline {
+		if _, err = PromptEnvDetails(); err != nil {
+			return err
+		}
+		lookupProblems, err := lookupOverrideProblems(ctx.Context, data)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, lookupProblems...)
+		sortProblemsByLine(problems)
+	}
+	for _, problem := range problems {
+		log.Error(problem)
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Message: fmt.Sprintf("Found %d problems in %s", len(problems), filePath)}
+	}
+	log.Infof("%s is valid", filePath)
+	return nil
+}
+
+// lookupOverrideProblems finds the overrides of entities that do not exist in First Gen & the overridden identifiers,
+// including the ones of the rules, that collide with other identifiers in the same scope
+func lookupOverrideProblems(ctx context.Context, data OverrideFileData) ([]string, error) {
+	var problems []string
+	owners := make(map[overrideTarget]overrideOwner)
+	var generated []overrideTarget
+	var generatedOwners []overrideOwner
+	claim := func(target overrideTarget, owner overrideOwner, subject string) {
+		if other, ok := owners[target]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: the identifier %s%s collides with %s in the %s scope", owner.line, target.identifier, subject, other.description, target.scope))
+			return
+		}
+		owners[target] = owner
+	}
+	ruleTypes := overrideRuleTypes(data.Rules)
+
+	for _, entityType := range overrideTypes {
+		var typeOverrides []EntityOverride
+		for _, override := range data.Overrides {
+			if override.Type == entityType {
+				typeOverrides = append(typeOverrides, override)
+			}
+		}
+		if len(typeOverrides) == 0 && !slices.Contains(ruleTypes, entityType) {
+			continue
+		}
+
+		var entities []BaseEntityDetail
+		listed := len(migrationReq.AppId) > 0 || !slices.Contains(appOverrideTypes, entityType)
+		if listed {
+			var err error
+			entities, err = listEntities(ctx, GetEndpointFromType(entityType))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the entities of type %s: %w", entityType, err)
+			}
+		} else {
+			log.Warnf("Skipping the lookup of the entities of type %s as no app was given. Use --app to provide one", entityType)
+		}
+
+		overridden := make(map[string]bool)
+		for _, override := range typeOverrides {
+			name := override.FirstGenName
+			if listed {
+				entity, ok := findOverriddenEntity(entities, override)
+				if !ok {
+					problems = append(problems, fmt.Sprintf("line %d: no %s found in first gen", override.line, describeOverride(override)))
+					continue
+				}
+				overridden[entity.Id] = true
+				name = entity.Name
+			}
+			owner := overrideOwner{line: override.line, description: fmt.Sprintf("the override on line %d", override.line), name: overrideNameOf(override, name)}
+			claim(overrideTargetOf(override, name), owner, "")
+		}
+		for _, entity := range entities {
+			if overridden[entity.Id] {
+				continue
+			}
+			override, ok, err := applyOverrideRules(data.Rules, entityType, entity)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if ok {
+				owner := overrideOwner{line: override.line, description: fmt.Sprintf("the rule on line %d for the %s named %s", override.line, entityType, entity.Name), name: overrideNameOf(override, entity.Name)}
+				claim(overrideTargetOf(override, entity.Name), owner, fmt.Sprintf(" of the %s named %s", entityType, entity.Name))
+				continue
+			}
+			generated = append(generated, overrideTarget{entityType: entityType, scope: defaultScopeOf(entityType), identifier: ToIdentifier(entity.Name)})
+			generatedOwners = append(generatedOwners, overrideOwner{description: fmt.Sprintf("the identifier generated for the %s named %s", entityType, entity.Name)})
+		}
+	}
+
+	for i, target := range generated {
+		if owner, ok := owners[target]; ok && owner.line > 0 {
+			problems = append(problems, fmt.Sprintf("line %d: the identifier %s collides with %s in the %s scope", owner.line, target.identifier, generatedOwners[i].description, target.scope))
+		}
+	}
+
+	existing := make(map[overrideTarget]map[string]string)
+	for target, owner := range owners {
+		if !slices.Contains(scopes, target.scope) {
+			continue
+		}
+		key := overrideTarget{entityType: target.entityType, scope: target.scope}
+		if _, ok := existing[key]; !ok {
+			names, err := getNextGenNames(ctx, target.entityType, target.scope)
+			if err != nil {
+				return nil, err
+			}
+			existing[key] = names
+		}
+		name, ok := existing[key][target.identifier]
+		if !ok {
+			continue
+		}
+		// An entity with the same name is most likely the one the override applies to, migrated by an earlier run
+		if name == owner.name {
+			log.Warnf("line %d: a %s with the identifier %s & the name %s already exists in the %s scope in next gen. It is likely migrated already", owner.line, target.entityType, target.identifier, name, target.scope)
+		} else {
+			problems = append(problems, fmt.Sprintf("line %d: a %s with the identifier %s already exists in the %s scope in next gen", owner.line, target.entityType, target.identifier, target.scope))
+		}
+	}
+	return problems, nil
+}
+
+// overrideTargetOf returns where the overridden entity ends up in Next Gen. The identifier is generated from the name
+// unless overridden.
+func overrideTargetOf(override EntityOverride, name string) overrideTarget {
+	target := overrideTarget{entityType: override.Type, scope: defaultScopeOf(override.Type), identifier: ToIdentifier(overrideNameOf(override, name))}
+	if override.Identifier != nil {
+		target.identifier = *override.Identifier
+	}
+	if override.Scope != nil {
+		target.scope = *override.Scope
+	}
+	return target
+}
+
+// overrideNameOf returns the name of the overridden entity in Next Gen
+func overrideNameOf(override EntityOverride, name string) string {
+	if override.Name != nil {
+		return *override.Name
+	}
+	return name
+}
+
+func findOverriddenEntity(entities []BaseEntityDetail, override EntityOverride) (BaseEntityDetail, bool) {
+	for _, entity := range entities {
+		if (len(override.ID) > 0 && entity.Id == override.ID) || (len(override.ID) == 0 && entity.Name == override.FirstGenName) {
+			return entity, true
+		}
+	}
+	return BaseEntityDetail{}, false
+}
+
+func describeOverride(override EntityOverride) string {
+	if len(override.ID) > 0 {
+		return fmt.Sprintf("%s with id %s", override.Type, override.ID)
+	}
+	return fmt.Sprintf("%s named %s", override.Type, override.FirstGenName)
+}
+
+// defaultScopeOf returns the scope the entities of the type are migrated to unless overridden
+func defaultScopeOf(entityType string) string {
+	switch entityType {
+	case Secret:
+		return getOrDefault(migrationReq.SecretScope, Project)
+	case Connector:
+		return getOrDefault(migrationReq.ConnectorScope, Project)
+	case Template:
+		return getOrDefault(migrationReq.TemplateScope, Project)
+	case Workflow:
+		return getOrDefault(migrationReq.WorkflowScope, Project)
+	case UserGroups:
+		return getOrDefault(migrationReq.UserGroupScope, Account)
+	default:
+		return Project
+	}
+}
+
+// getNextGenNames returns the names of the entities of the type that exist in the scope in Next Gen by their
+// identifiers. It returns nil if the entities of the type cannot be looked up.
+func getNextGenNames(ctx context.Context, entityType string, scope string) (map[string]string, error) {
+	orgId, projectId := "", ""
+	if scope != Account {
+		orgId = migrationReq.OrgIdentifier
+	}
+	if scope == Project {
+		projectId = migrationReq.ProjectIdentifier
+	}
+	if (scope != Account && len(orgId) == 0) || (scope == Project && len(projectId) == 0) {
+		log.Warnf("Skipping the lookup of the entities of type %s in the %s scope in next gen. Use --org & --project to look them up", entityType, scope)
+		return nil, nil
+	}
+
+	names := make(map[string]string)
+	switch entityType {
+	case Secret:
+		secrets, err := getSecrets(ctx, orgId, projectId)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			names[secret.Identifier] = secret.Name
+		}
+	case Template:
+		templates, err := getTemplates(ctx, orgId, projectId, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range templates {
+			names[template.Identifier] = template.Name
+		}
+	case Pipeline:
+		if scope != Project {
+			return nil, nil
+		}
+		pipelines, err := getPipelines(ctx, orgId, projectId)
+		if err != nil {
+			return nil, err
+		}
+		for _, pipeline := range pipelines {
+			names[pipeline.Identifier] = pipeline.Name
+		}
+	case Connector:
+		return getNextGenEntityNames(ctx, "api/connectors", "pageIndex", "pageSize", "connector", orgId, projectId)
+	case Service:
+		return getNextGenEntityNames(ctx, "api/servicesV2", "page", "size", "service", orgId, projectId)
+	case Environment:
+		return getNextGenEntityNames(ctx, "api/environmentsV2", "page", "size", "environment", orgId, projectId)
+	default:
+		return nil, nil
+	}
+	return names, nil
+}
+
+// getNextGenEntityNames lists the names of the entities of a Next Gen endpoint by their identifiers. The items of the
+// endpoint hold the entity under the key.
+func getNextGenEntityNames(ctx context.Context, endpoint string, pageParam string, pageSizeParam string, key string, orgId string, projectId string) (map[string]string, error) {
+	queryParams := map[string]string{
+		AccountIdentifier: migrationReq.Account,
+		pageSizeParam:     "1000",
+	}
+	if len(orgId) > 0 {
+		queryParams[OrgIdentifier] = orgId
+	}
+	if len(projectId) > 0 {
+		queryParams[ProjectIdentifier] = projectId
+	}
+	names := make(map[string]string)
+	for page := 0; ; page++ {
+		queryParams[pageParam] = strconv.Itoa(page)
+		url, err := GetUrlWithQueryParams(migrationReq.Environment, NextGenService, endpoint, queryParams)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := Get(ctx, url, migrationReq.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %ss: %w", key, err)
+		}
+		if resp.Status != "SUCCESS" {
+			return nil, &APIError{StatusCode: 200, Url: url, Message: resp.Message, Messages: resp.Messages}
+		}
+		byteData, err := json.Marshal(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %ss: %w", key, err)
+		}
+		var listBody NextGenListBody
+		if err = json.Unmarshal(byteData, &listBody); err != nil {
+			return nil, fmt.Errorf("failed to fetch %ss: %w", key, err)
+		}
+		for _, item := range listBody.Content {
+			names[item[key].Identifier] = item[key].Name
+		}
+		if !listBody.hasNextPage(page) {
+			return names, nil
+		}
+	}
+}
+
+// sortProblemsByLine sorts problems that start with `line N:` by their line
+func sortProblemsByLine(problems []string) {
+	lineOf := func(problem string) int {
+		var line int
+		_, _ = fmt.Sscanf(problem, "line %d:", &line)
+		return line
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return lineOf(problems[i]) < lineOf(problems[j])
+	})
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Position of a character in the source an expression was parsed from. Lines & columns start at 1.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Expression is a First Gen expression `${...}` found in a text
+type Expression struct {
+	// Start is the position of `$` & End the position right after the closing `}`
+	Start Position
+	End   Position
+	// Raw is the source of the expression as is
+	Raw  string
+	Body Node
+	// Nested are the expressions within the body, e.g. `${env.name}` in `${workflow.variables.${env.name}}`
+	Nested []*Expression
+}
+
+// Node is a node of the syntax tree of the body of an expression
+type Node interface {
+	// Pos is the offset of the node in the source
+	Pos() int
+	// render returns the canonical form of the node, with nested expressions written using the given function
+	render(nested func(e *Expression) string) string
+}
+
+// Ident is a name like `workflow` in `workflow.name`
+type Ident struct {
+	Offset int
+	Name   string
+}
+
+// Selector is a field access like `workflow.name`
+type Selector struct {
+	X   Node
+	Sel Node
+}
+
+// Index is an index access like `context.list[0]` or `context.map['key']`
+type Index struct {
+	X     Node
+	Index Node
+}
+
+// Call is a method call like `secrets.getValue("name")`
+type Call struct {
+	Fun  Node
+	Args []Node
+}
+
+// Literal is a string or number. Strings keep their quotes.
+type Literal struct {
+	Offset int
+	Value  string
+}
+
+// NestedExpr is an expression used within another expression
+type NestedExpr struct {
+	Expr *Expression
+}
+
+// Raw holds the body of an expression that is not a plain path, method call or index access like `${a == b ? c : d}`
+type Raw struct {
+	Offset int
+	Text   string
+}
+
+func (n *Ident) Pos() int      { return n.Offset }
+func (n *Selector) Pos() int   { return n.X.Pos() }
+func (n *Index) Pos() int      { return n.X.Pos() }
+func (n *Call) Pos() int       { return n.Fun.Pos() }
+func (n *Literal) Pos() int    { return n.Offset }
+func (n *NestedExpr) Pos() int { return n.Expr.Start.Offset }
+func (n *Raw) Pos() int        { return n.Offset }
+
+func (n *Ident) render(func(e *Expression) string) string { return n.Name }
+func (n *Selector) render(nested func(e *Expression) string) string {
+	return n.X.render(nested) + "." + n.Sel.render(nested)
+}
+func (n *Index) render(nested func(e *Expression) string) string {
+	return n.X.render(nested) + "[" + n.Index.render(nested) + "]"
+}
+func (n *Call) render(nested func(e *Expression) string) string {
+	var args []string
+	for _, arg := range n.Args {
+		args = append(args, arg.render(nested))
+	}
+	return n.Fun.render(nested) + "(" + strings.Join(args, ", ") + ")"
+}
+func (n *Literal) render(func(e *Expression) string) string           { return n.Value }
+func (n *NestedExpr) render(nested func(e *Expression) string) string { return nested(n.Expr) }
+func (n *Raw) render(func(e *Expression) string) string               { return n.Text }
+
+// Key is the canonical form of the body that is used to look up the Next Gen equivalent. Whitespace is dropped &
+// nested expressions are kept as is.
+func (e *Expression) Key() string {
+	return e.Body.render(func(n *Expression) string { return n.Raw })
+}
+
+// IsPath reports if the body is a path, method call or index access that can be converted
+func (e *Expression) IsPath() bool {
+	_, isRaw := e.Body.(*Raw)
+	return !isRaw
+}
+
+// ParseExpressions finds all the top level expressions in the source in the order they appear
+func ParseExpressions(src string) []*Expression {
+	p := &parser{src: src, lines: lineOffsets(src)}
+	var expressions []*Expression
+	for i := 0; i < len(src)-1; i++ {
+		if src[i] != '$' || src[i+1] != '{' {
+			continue
+		}
+		if e := p.parseExpression(i); e != nil {
+			expressions = append(expressions, e)
+			i = e.End.Offset - 1
+		}
+	}
+	return expressions
+}
+
+type parser struct {
+	src   string
+	lines []int
+}
+
+func lineOffsets(src string) []int {
+	lines := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func (p *parser) position(offset int) Position {
+	line := sort.Search(len(p.lines), func(i int) bool { return p.lines[i] > offset }) - 1
+	return Position{Offset: offset, Line: line + 1, Column: offset - p.lines[line] + 1}
+}
+
+// parseExpression parses the expression that starts with `${` at the offset. Unterminated expressions are not
+// expressions.
+func (p *parser) parseExpression(start int) *Expression {
+	end := p.findEnd(start + 2)
+	if end < 0 {
+		return nil
+	}
+	e := &Expression{
+		Start: p.position(start),
+		End:   p.position(end + 1),
+		Raw:   p.src[start : end+1],
+	}
+	tokens := p.tokenize(start+2, end, e)
+	body, rest := parseChain(tokens)
+	if body == nil || len(rest) > 0 {
+		body = &Raw{Offset: start + 2, Text: strings.TrimSpace(p.src[start+2 : end])}
+	}
+	e.Body = body
+	return e
+}
+
+// findEnd returns the offset of the `}` that closes the expression whose body starts at the offset
+func (p *parser) findEnd(offset int) int {
+	depth := 1
+	var quote byte
+	for i := offset; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case c == '\n':
+			// Expressions do not span lines
+			return -1
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case c == '$' && i+1 < len(p.src) && p.src[i+1] == '{':
+			// Nested expressions are skipped as a whole as they can be within strings & have strings of their own
+			end := p.findEnd(i + 2)
+			if end < 0 {
+				return -1
+			}
+			i = end
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	literalToken
+	nestedToken
+	punctToken
+	otherToken
+)
+
+type token struct {
+	kind   tokenKind
+	offset int
+	text   string
+	expr   *Expression
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
+
+// tokenize splits the body of an expression between the offsets into tokens. Nested expressions are parsed & added
+// to the parent.
+func (p *parser) tokenize(from int, to int, parent *Expression) []token {
+	var tokens []token
+	for i := from; i < to; {
+		c := p.src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '$' && i+1 < to && p.src[i+1] == '{':
+			nested := p.parseExpression(i)
+			if nested == nil || nested.End.Offset > to {
+				tokens = append(tokens, token{kind: otherToken, offset: i, text: "$"})
+				i++
+				continue
+			}
+			parent.Nested = append(parent.Nested, nested)
+			tokens = append(tokens, token{kind: nestedToken, offset: i, text: nested.Raw, expr: nested})
+			i = nested.End.Offset
+		case isIdentStart(c):
+			j := i + 1
+			for j < to && isIdentPart(p.src[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: identToken, offset: i, text: p.src[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < to && p.src[j] >= '0' && p.src[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, token{kind: literalToken, offset: i, text: p.src[i:j]})
+			i = j
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < to && p.src[j] != c {
+				if p.src[j] == '\\' {
+					j++
+				} else if p.src[j] == '$' && j+1 < to && p.src[j+1] == '{' {
+					// Expressions within strings are nested expressions as well
+					if nested := p.parseExpression(j); nested != nil && nested.End.Offset <= to {
+						parent.Nested = append(parent.Nested, nested)
+						j = nested.End.Offset
+						continue
+					}
+				}
+				j++
+			}
+			if j >= to {
+				tokens = append(tokens, token{kind: otherToken, offset: i, text: p.src[i:to]})
+				i = to
+				continue
+			}
+			tokens = append(tokens, token{kind: literalToken, offset: i, text: p.src[i : j+1]})
+			i = j + 1
+		case strings.IndexByte(".()[],", c) >= 0:
+			tokens = append(tokens, token{kind: punctToken, offset: i, text: string(c)})
+			i++
+		default:
+			tokens = append(tokens, token{kind: otherToken, offset: i, text: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isPunct(tokens []token, text string) bool {
+	return len(tokens) > 0 && tokens[0].kind == punctToken && tokens[0].text == text
+}
+
+// parseChain parses a primary followed by any number of selectors, index accesses & calls. It returns the tokens
+// that are left.
+func parseChain(tokens []token) (Node, []token) {
+	if len(tokens) == 0 {
+		return nil, tokens
+	}
+	var x Node
+	switch t := tokens[0]; t.kind {
+	case identToken:
+		x = &Ident{Offset: t.offset, Name: t.text}
+	case literalToken:
+		x = &Literal{Offset: t.offset, Value: t.text}
+	case nestedToken:
+		x = &NestedExpr{Expr: t.expr}
+	default:
+		return nil, tokens
+	}
+	tokens = tokens[1:]
+	for {
+		switch {
+		case isPunct(tokens, "."):
+			if len(tokens) < 2 {
+				return nil, tokens
+			}
+			switch t := tokens[1]; t.kind {
+			case identToken:
+				x = &Selector{X: x, Sel: &Ident{Offset: t.offset, Name: t.text}}
+			case nestedToken:
+				x = &Selector{X: x, Sel: &NestedExpr{Expr: t.expr}}
+			case literalToken:
+				// Numbers after a dot like `list.0`
+				x = &Selector{X: x, Sel: &Literal{Offset: t.offset, Value: t.text}}
+			default:
+				return nil, tokens
+			}
+			tokens = tokens[2:]
+		case isPunct(tokens, "["):
+			index, rest := parseChain(tokens[1:])
+			if index == nil || !isPunct(rest, "]") {
+				return nil, tokens
+			}
+			x = &Index{X: x, Index: index}
+			tokens = rest[1:]
+		case isPunct(tokens, "("):
+			call := &Call{Fun: x}
+			rest := tokens[1:]
+			for !isPunct(rest, ")") {
+				arg, r := parseChain(rest)
+				if arg == nil {
+					return nil, tokens
+				}
+				call.Args = append(call.Args, arg)
+				rest = r
+				if isPunct(rest, ",") {
+					rest = rest[1:]
+				} else if !isPunct(rest, ")") {
+					return nil, tokens
+				}
+			}
+			x = call
+			tokens = rest[1:]
+		default:
+			return x, tokens
+		}
+	}
+}
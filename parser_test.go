@@ -0,0 +1,232 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parsedExpression is the part of an Expression that the tests compare
+type parsedExpression struct {
+	Raw    string
+	Key    string
+	IsPath bool
+	Nested []string
+}
+
+func summarise(expressions []*Expression) []parsedExpression {
+	var result []parsedExpression
+	for _, e := range expressions {
+		p := parsedExpression{Raw: e.Raw, Key: e.Key(), IsPath: e.IsPath()}
+		for _, n := range e.Nested {
+			p.Nested = append(p.Nested, n.Key())
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func TestParseExpressions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []parsedExpression
+	}{
+		{
+			name: "path",
+			src:  "name: ${workflow.name}",
+			want: []parsedExpression{{Raw: "${workflow.name}", Key: "workflow.name", IsPath: true}},
+		},
+		{
+			name: "nested",
+			src:  "${a.${b}}",
+			want: []parsedExpression{{Raw: "${a.${b}}", Key: "a.${b}", IsPath: true, Nested: []string{"b"}}},
+		},
+		{
+			name: "nested path",
+			src:  "${workflow.variables.${env.name}}",
+			want: []parsedExpression{{Raw: "${workflow.variables.${env.name}}", Key: "workflow.variables.${env.name}", IsPath: true, Nested: []string{"env.name"}}},
+		},
+		{
+			name: "nested in a string argument",
+			src:  `${secrets.getValue("db-${env.name}")}`,
+			want: []parsedExpression{{Raw: `${secrets.getValue("db-${env.name}")}`, Key: `secrets.getValue("db-${env.name}")`, IsPath: true, Nested: []string{"env.name"}}},
+		},
+		{
+			name: "whitespace inside the braces",
+			src:  "${ workflow.name }",
+			want: []parsedExpression{{Raw: "${ workflow.name }", Key: "workflow.name", IsPath: true}},
+		},
+		{
+			name: "whitespace around arguments",
+			src:  `${app.name.substring( 0 ,3 )}`,
+			want: []parsedExpression{{Raw: `${app.name.substring( 0 ,3 )}`, Key: "app.name.substring(0, 3)", IsPath: true}},
+		},
+		{
+			name: "double quoted argument",
+			src:  `${secrets.getValue("my secret")}`,
+			want: []parsedExpression{{Raw: `${secrets.getValue("my secret")}`, Key: `secrets.getValue("my secret")`, IsPath: true}},
+		},
+		{
+			name: "single quoted argument",
+			src:  `${secrets.getValue('my secret')}`,
+			want: []parsedExpression{{Raw: `${secrets.getValue('my secret')}`, Key: `secrets.getValue('my secret')`, IsPath: true}},
+		},
+		{
+			name: "escaped quote & brace in a double quoted argument",
+			src:  `${secrets.getValue("a\"}b")}`,
+			want: []parsedExpression{{Raw: `${secrets.getValue("a\"}b")}`, Key: `secrets.getValue("a\"}b")`, IsPath: true}},
+		},
+		{
+			name: "escaped quote in a single quoted argument",
+			src:  `${secrets.getValue('it\'s')}`,
+			want: []parsedExpression{{Raw: `${secrets.getValue('it\'s')}`, Key: `secrets.getValue('it\'s')`, IsPath: true}},
+		},
+		{
+			name: "index access",
+			src:  "${context.list[0]}",
+			want: []parsedExpression{{Raw: "${context.list[0]}", Key: "context.list[0]", IsPath: true}},
+		},
+		{
+			name: "index access with a key",
+			src:  "${context.map[ 'key' ]}",
+			want: []parsedExpression{{Raw: "${context.map[ 'key' ]}", Key: "context.map['key']", IsPath: true}},
+		},
+		{
+			name: "method chain",
+			src:  "${workflow.name.toLowerCase().trim()}",
+			want: []parsedExpression{{Raw: "${workflow.name.toLowerCase().trim()}", Key: "workflow.name.toLowerCase().trim()", IsPath: true}},
+		},
+		{
+			name: "raw body",
+			src:  "${a == b ? c : d}",
+			want: []parsedExpression{{Raw: "${a == b ? c : d}", Key: "a == b ? c : d", IsPath: false}},
+		},
+		{
+			name: "raw body with nested expressions",
+			src:  "${ ${a} + 1 }",
+			want: []parsedExpression{{Raw: "${ ${a} + 1 }", Key: "${a} + 1", IsPath: false, Nested: []string{"a"}}},
+		},
+		{
+			name: "adjacent expressions",
+			src:  "${a}${b.c}",
+			want: []parsedExpression{{Raw: "${a}", Key: "a", IsPath: true}, {Raw: "${b.c}", Key: "b.c", IsPath: true}},
+		},
+		{
+			name: "unterminated",
+			src:  "name: ${workflow.name",
+			want: nil,
+		},
+		{
+			name: "unterminated nested",
+			src:  "${a.${b",
+			want: nil,
+		},
+		{
+			name: "multi-line",
+			src:  "${workflow.\nname}",
+			want: nil,
+		},
+		{
+			name: "multi-line string argument",
+			src:  "${secrets.getValue(\"a\nb\")}",
+			want: nil,
+		},
+		{
+			name: "no expressions",
+			src:  "$workflow.name {x} $ {y}",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarise(ParseExpressions(tt.src))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseExpressions(%q) = %+v, want %+v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpressionsPositions(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		wantStarts []Position
+		wantEnds   []Position
+	}{
+		{
+			name:       "single line",
+			src:        "a: ${x}",
+			wantStarts: []Position{{Offset: 3, Line: 1, Column: 4}},
+			wantEnds:   []Position{{Offset: 7, Line: 1, Column: 8}},
+		},
+		{
+			name:       "multiple lines",
+			src:        "a: ${x}\n  b: ${y.z}\n\nc: ${ w }",
+			wantStarts: []Position{{Offset: 3, Line: 1, Column: 4}, {Offset: 13, Line: 2, Column: 6}, {Offset: 24, Line: 4, Column: 4}},
+			wantEnds:   []Position{{Offset: 7, Line: 1, Column: 8}, {Offset: 19, Line: 2, Column: 12}, {Offset: 30, Line: 4, Column: 10}},
+		},
+		{
+			name:       "nested",
+			src:        "\n${a.${b}}",
+			wantStarts: []Position{{Offset: 1, Line: 2, Column: 1}},
+			wantEnds:   []Position{{Offset: 10, Line: 2, Column: 10}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var starts, ends []Position
+			for _, e := range ParseExpressions(tt.src) {
+				starts = append(starts, e.Start)
+				ends = append(ends, e.End)
+			}
+			if !reflect.DeepEqual(starts, tt.wantStarts) {
+				t.Errorf("starts = %+v, want %+v", starts, tt.wantStarts)
+			}
+			if !reflect.DeepEqual(ends, tt.wantEnds) {
+				t.Errorf("ends = %+v, want %+v", ends, tt.wantEnds)
+			}
+		})
+	}
+}
+
+func TestParseExpressionsNestedPositions(t *testing.T) {
+	expressions := ParseExpressions("x: ${a.${b}}")
+	if len(expressions) != 1 || len(expressions[0].Nested) != 1 {
+		t.Fatalf("expected one expression with one nested expression, got %+v", summarise(expressions))
+	}
+	nested := expressions[0].Nested[0]
+	if want := (Position{Offset: 7, Line: 1, Column: 8}); nested.Start != want {
+		t.Errorf("nested start = %+v, want %+v", nested.Start, want)
+	}
+	if want := (Position{Offset: 11, Line: 1, Column: 12}); nested.End != want {
+		t.Errorf("nested end = %+v, want %+v", nested.End, want)
+	}
+}
+
+func TestExpressionKey(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "${workflow.name}", want: "workflow.name"},
+		{src: "${  workflow . name  }", want: "workflow.name"},
+		{src: "${secrets.getValue( 'a' )}", want: "secrets.getValue('a')"},
+		{src: "${f(a,b , c)}", want: "f(a, b, c)"},
+		{src: "${list [ 0 ] . name}", want: "list[0].name"},
+		{src: "${workflow.variables.${ env.name }}", want: "workflow.variables.${ env.name }"},
+		{src: "${service-variable.my-var}", want: "service-variable.my-var"},
+		{src: "${list.0}", want: "list.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expressions := ParseExpressions(tt.src)
+			if len(expressions) != 1 {
+				t.Fatalf("ParseExpressions(%q) returned %d expressions", tt.src, len(expressions))
+			}
+			if got := expressions[0].Key(); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
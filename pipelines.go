@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
@@ -8,71 +9,107 @@ import (
 	"strconv"
 )
 
-func migratePipelines(*cli.Context) error {
-	promptConfirm := PromptDefaultInputs()
+func migratePipelines(ctx *cli.Context) error {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
 		promptConfirm = true
-		migrationReq.AppId = TextInput("Please provide the application ID of the app containing the pipeline -")
+		migrationReq.AppId, err = TextInput("Please provide the application ID of the app containing the pipeline -")
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.WorkflowScope) == 0 {
 		promptConfirm = true
-		migrationReq.WorkflowScope = SelectInput("Scope for workflow to be migrated as templates:", scopes, Project)
+		migrationReq.WorkflowScope, err = SelectInput("Scope for workflow to be migrated as templates:", scopes, Project)
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.PipelineIds) == 0 && !migrationReq.All {
 		allPipelinesConfirm := ConfirmInput("No pipelines provided. This defaults to migrating all pipelines within the application. Do you want to proceed?")
 		if !allPipelinesConfirm {
 			promptConfirm = true
-			migrationReq.PipelineIds = TextInput("Provide the pipelines that you wish to import as template as comma separated values(e.g. pipeline1,pipeline2)")
+			migrationReq.PipelineIds, err = TextInput("Provide the pipelines that you wish to import as template as comma separated values(e.g. pipeline1,pipeline2)")
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	promptConfirm = PromptOrgAndProject([]string{Project}) || promptConfirm
+	promptConfirm, err = PromptOrgAndProject([]string{Project}, promptConfirm)
+	if err != nil {
+		return err
+	}
 
 	logMigrationDetails()
 
+	dependencies, err := getDependencyImports(ctx.Context, Pipeline, len(migrationReq.PipelineIds) > 0)
+	if err != nil {
+		return err
+	}
+
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with pipeline migration?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
+	if err := migrateDependencies(ctx.Context, dependencies); err != nil {
+		return err
+	}
+
 	// Migrating the pipelines
 	log.Info("Importing the pipelines....")
 	var pipelineIds []string
 	if len(migrationReq.PipelineIds) > 0 {
 		pipelineIds = Split(migrationReq.PipelineIds, ",")
 	}
-	CreateEntities(getReqBody(Pipeline, Filter{
+	err = createEntities(ctx.Context, Pipeline, Filter{
 		PipelineIds: pipelineIds,
 		AppId:       migrationReq.AppId,
-	}))
+	})
+	if err != nil {
+		return err
+	}
 	log.Info("Imported the pipelines.")
 
 	return nil
 }
 
-func BulkRemovePipelines(*cli.Context) error {
-	promptConfirm := PromptEnvDetails()
-	promptConfirm = PromptOrgAndProject([]string{Project}) || promptConfirm
+func BulkRemovePipelines(ctx *cli.Context) error {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	promptConfirm, err = PromptOrgAndProject([]string{Project}, promptConfirm)
+	if err != nil {
+		return err
+	}
 	names := Split(migrationReq.Names, ",")
 	identifiers := Split(migrationReq.Identifiers, ",")
 
 	if migrationReq.All {
 		identifiers = []string{}
-		pipelines := getPipelines(migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier)
+		pipelines, err := getPipelines(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier)
+		if err != nil {
+			return err
+		}
 		for _, pipeline := range pipelines {
 			identifiers = append(identifiers, pipeline.Identifier)
 		}
 	}
 
 	if len(names) == 0 && len(identifiers) == 0 {
-		log.Fatal("No names or identifiers for the pipelines provided. Aborting")
+		return &ValidationError{Message: "No names or identifiers for the pipelines provided. Aborting"}
 	}
 	if len(names) > 0 && len(identifiers) > 0 {
-		log.Fatal("Both names and identifiers for the pipelines provided. Aborting")
+		return &ValidationError{Message: "Both names and identifiers for the pipelines provided. Aborting"}
 	}
 
 	n := len(identifiers)
@@ -82,12 +119,15 @@ func BulkRemovePipelines(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Are you sure you want to proceed with deletion of " + strconv.Itoa(n) + " pipelines?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	if len(names) > 0 {
-		pipelines := getPipelines(migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier)
+		pipelines, err := getPipelines(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier)
+		if err != nil {
+			return err
+		}
 		for _, name := range names {
 			id := findPipelineIdByName(pipelines, name)
 			if len(id) > 0 {
@@ -97,54 +137,71 @@ func BulkRemovePipelines(*cli.Context) error {
 		log.Debugf("Valid identifiers for the given names are - %s", identifiers)
 	}
 
-	for _, identifier := range identifiers {
-		deletePipeline(migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, identifier)
-	}
+	forEachConcurrently(ctx.Context, identifiers, migrationReq.Concurrency, func(identifier string) {
+		deletePipeline(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, identifier)
+	})
 	log.Info("Finished operation for all given pipelines")
 	return nil
 }
 
-func deletePipeline(orgId string, projectId string, pipelineId string) {
+func deletePipeline(ctx context.Context, orgId string, projectId string, pipelineId string) {
 	queryParams := map[string]string{
 		ProjectIdentifier: projectId,
 		OrgIdentifier:     orgId,
 		AccountIdentifier: migrationReq.Account,
 	}
-	url := GetUrlWithQueryParams(migrationReq.Environment, PipelineService, fmt.Sprintf("api/pipelines/%s", pipelineId), queryParams)
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, PipelineService, fmt.Sprintf("api/pipelines/%s", pipelineId), queryParams)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to delete the pipeline - %s", pipelineId)
+		return
+	}
 
 	log.Infof("Deleting the pipeline with identifier %s", pipelineId)
 
-	_, err := Delete(url, migrationReq.Auth, nil)
+	_, err = Delete(ctx, url, migrationReq.Auth, nil)
 
 	if err == nil {
 		log.Infof("Successfully deleted the pipeline - %s", pipelineId)
 	} else {
-		log.Errorf("Failed to delete the pipeline - %s", pipelineId)
+		log.WithError(err).Errorf("Failed to delete the pipeline - %s", pipelineId)
 	}
 }
 
-func getPipelines(orgId string, projectId string) []PipelineDetails {
+func getPipelines(ctx context.Context, orgId string, projectId string) ([]PipelineDetails, error) {
 	queryParams := map[string]string{
 		ProjectIdentifier: projectId,
 		OrgIdentifier:     orgId,
 		AccountIdentifier: migrationReq.Account,
 		"size":            "1000",
 	}
-	url := GetUrlWithQueryParams(migrationReq.Environment, PipelineService, "api/pipelines/list", queryParams)
-	resp, err := Post(url, migrationReq.Auth, FilterRequestBody{FilterType: "PipelineSetup"})
-	if err != nil || resp.Status != "SUCCESS" {
-		log.Fatal("Failed to fetch pipelines", err)
-	}
-	byteData, err := json.Marshal(resp.Data)
-	if err != nil {
-		log.Fatal("Failed to fetch pipelines", err)
-	}
-	var pipelineListBody PipelineListBody
-	err = json.Unmarshal(byteData, &pipelineListBody)
-	if err != nil {
-		log.Fatal("Failed to fetch pipelines", err)
+	var pipelines []PipelineDetails
+	for page := 0; ; page++ {
+		queryParams["page"] = strconv.Itoa(page)
+		url, err := GetUrlWithQueryParams(migrationReq.Environment, PipelineService, "api/pipelines/list", queryParams)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := Post(ctx, url, migrationReq.Auth, FilterRequestBody{FilterType: "PipelineSetup"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pipelines: %w", err)
+		}
+		if resp.Status != "SUCCESS" {
+			return nil, &APIError{StatusCode: 200, Url: url, Message: resp.Message, Messages: resp.Messages}
+		}
+		byteData, err := json.Marshal(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pipelines: %w", err)
+		}
+		var pipelineListBody PipelineListBody
+		err = json.Unmarshal(byteData, &pipelineListBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pipelines: %w", err)
+		}
+		pipelines = append(pipelines, pipelineListBody.Pipelines...)
+		if !pipelineListBody.hasNextPage(page) {
+			return pipelines, nil
+		}
 	}
-	return pipelineListBody.Pipelines
 }
 
 func findPipelineIdByName(pipelines []PipelineDetails, name string) string {
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Outcomes of the steps of a plan
+const (
+	StepOutcomeDone    = "DONE"
+	StepOutcomeFailed  = "FAILED"
+	StepOutcomeSkipped = "SKIPPED"
+)
+
+type MigrationPlan struct {
+	Org               string     `yaml:"org"`
+	Project           string     `yaml:"project"`
+	Scopes            PlanScopes `yaml:"scopes"`
+	IdentifierFormat  string     `yaml:"identifierFormat"`
+	Overrides         string     `yaml:"overrides"`
+	CustomExpressions string     `yaml:"customExpressions"`
+	AccountLevel      bool       `yaml:"accountLevel"`
+	Apps              []PlanApp  `yaml:"apps"`
+}
+
+type PlanScopes struct {
+	Secret    string `yaml:"secret"`
+	Connector string `yaml:"connector"`
+	Template  string `yaml:"template"`
+	Workflow  string `yaml:"workflow"`
+}
+
+type PlanApp struct {
+	App               string       `yaml:"app"`
+	Org               string       `yaml:"org"`
+	Project           string       `yaml:"project"`
+	Scopes            PlanScopes   `yaml:"scopes"`
+	Overrides         string       `yaml:"overrides"`
+	CustomExpressions string       `yaml:"customExpressions"`
+	Workflows         EntitySelect `yaml:"workflows"`
+	Pipelines         EntitySelect `yaml:"pipelines"`
+	Triggers          EntitySelect `yaml:"triggers"`
+}
+
+// EntitySelect is either `all` or a list of First Gen ids
+type EntitySelect struct {
+	All bool
+	Ids []string
+}
+
+func (s *EntitySelect) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "all" {
+			return fmt.Errorf("line %d: expected `all` or a list of ids but found %s", node.Line, node.Value)
+		}
+		s.All = true
+		return nil
+	}
+	return node.Decode(&s.Ids)
+}
+
+func (s EntitySelect) isEmpty() bool {
+	return !s.All && len(s.Ids) == 0
+}
+
+// PlanStep is a single call to create entities along with the inputs it is made with
+type PlanStep struct {
+	Name              string
+	Group             string
+	EntityType        EntityType
+	Filter            Filter
+	Org               string
+	Project           string
+	Scopes            PlanScopes
+	Overrides         string
+	CustomExpressions string
+}
+
+func ShowPlan(*cli.Context) error {
+	plan, err := loadPlan(migrationReq.PlanFile)
+	if err != nil {
+		return err
+	}
+	steps := getPlanSteps(plan)
+	var rows []table.Row
+	for i, step := range steps {
+		rows = append(rows, table.Row{i + 1, step.Name, step.Org, step.Project, step.Scopes.Secret, step.Scopes.Connector, step.Scopes.Template, step.Scopes.Workflow})
+	}
+	renderPlanTable(table.Row{"#", "Step", "Org", "Project", "Secret Scope", "Connector Scope", "Template Scope", "Workflow Scope"}, rows)
+	log.Infof("The plan is valid & has %d steps", len(steps))
+	return nil
+}
+
+func ApplyPlan(ctx *cli.Context) error {
+	plan, err := loadPlan(migrationReq.PlanFile)
+	if err != nil {
+		return err
+	}
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	steps := getPlanSteps(plan)
+	log.WithFields(log.Fields{
+		"Account": migrationReq.Account,
+		"Plan":    migrationReq.PlanFile,
+		"Steps":   len(steps),
+	}).Info("Plan details")
+	if promptConfirm {
+		confirm := ConfirmInput("Do you want to apply the plan?")
+		if !confirm {
+			return &AbortedByUser{}
+		}
+	}
+	if len(plan.IdentifierFormat) > 0 {
+		migrationReq.IdentifierCase = plan.IdentifierFormat
+	}
+
+	outcomes := make([]string, len(steps))
+	var firstErr error
+	failedGroups := make(map[string]bool)
+	for i, step := range steps {
+		if failedGroups[step.Group] || failedGroups[""] {
+			outcomes[i] = StepOutcomeSkipped
+			continue
+		}
+		log.Infof("Step %d of %d - %s", i+1, len(steps), step.Name)
+		err = applyPlanStep(ctx.Context, step)
+		if err != nil {
+			log.WithError(err).Errorf("Step %d failed", i+1)
+			outcomes[i] = StepOutcomeFailed
+			// The remaining steps of the app depend on this step. A failure of an account level step fails all
+			failedGroups[step.Group] = true
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Context.Err() != nil {
+				break
+			}
+			continue
+		}
+		outcomes[i] = StepOutcomeDone
+	}
+
+	var rows []table.Row
+	for i, step := range steps {
+		outcome := outcomes[i]
+		if len(outcome) == 0 {
+			outcome = StepOutcomeSkipped
+		}
+		rows = append(rows, table.Row{i + 1, step.Name, outcome})
+	}
+	renderPlanTable(table.Row{"#", "Step", "Outcome"}, rows)
+	return firstErr
+}
+
+func applyPlanStep(ctx context.Context, step PlanStep) error {
+	migrationReq.OrgIdentifier = step.Org
+	migrationReq.ProjectIdentifier = step.Project
+	migrationReq.AppId = step.Filter.AppId
+	migrationReq.SecretScope = step.Scopes.Secret
+	migrationReq.ConnectorScope = step.Scopes.Connector
+	migrationReq.TemplateScope = step.Scopes.Template
+	migrationReq.WorkflowScope = step.Scopes.Workflow
+	migrationReq.OverrideFile = step.Overrides
+	migrationReq.CustomExpressionsFile = step.CustomExpressions
+	return createEntities(ctx, step.EntityType, step.Filter)
+}
+
+func loadPlan(filePath string) (plan MigrationPlan, err error) {
+	if len(strings.TrimSpace(filePath)) == 0 {
+		return plan, &ValidationError{Message: "Provide the plan file using --file"}
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal(content, &plan)
+	if err != nil {
+		return plan, &ValidationError{Message: fmt.Sprintf("invalid plan file %s - %s", filePath, err)}
+	}
+	if problems := validatePlan(plan); len(problems) > 0 {
+		for _, problem := range problems {
+			log.Error(problem)
+		}
+		return plan, &ValidationError{Message: fmt.Sprintf("The plan file %s has %d problems", filePath, len(problems))}
+	}
+	return plan, nil
+}
+
+func validatePlan(plan MigrationPlan) (problems []string) {
+	validateScopes := func(prefix string, s PlanScopes) {
+		for _, scope := range []struct{ name, value string }{{"secret", s.Secret}, {"connector", s.Connector}, {"template", s.Template}, {"workflow", s.Workflow}} {
+			if len(scope.value) > 0 && !slices.Contains(scopes, scope.value) {
+				problems = append(problems, fmt.Sprintf("%s: invalid %s scope %s. Possible values - %s", prefix, scope.name, scope.value, strings.Join(scopes, ", ")))
+			}
+		}
+	}
+	validateFile := func(prefix string, name string, filePath string) {
+		if len(filePath) == 0 {
+			return
+		}
+		if _, err := os.Stat(filePath); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s file %s cannot be read - %s", prefix, name, filePath, err))
+		}
+	}
+
+	validateScopes("plan", plan.Scopes)
+	validateFile("plan", "overrides", plan.Overrides)
+	validateFile("plan", "custom expressions", plan.CustomExpressions)
+	if len(plan.IdentifierFormat) > 0 {
+		if !slices.Contains([]string{"CAMEL_CASE", "LOWER_CASE"}, plan.IdentifierFormat) {
+			problems = append(problems, fmt.Sprintf("plan: invalid identifierFormat %s. Possible values - CAMEL_CASE, LOWER_CASE", plan.IdentifierFormat))
+		}
+	}
+	if plan.AccountLevel {
+		for _, scope := range []string{plan.Scopes.Secret, plan.Scopes.Connector} {
+			scope = getOrDefault(scope, Project)
+			if scope != Account && len(plan.Org) == 0 {
+				problems = append(problems, "plan: org is required to migrate account level entities to org or project scope")
+				break
+			}
+			if scope == Project && len(plan.Project) == 0 {
+				problems = append(problems, "plan: project is required to migrate account level entities to project scope")
+				break
+			}
+		}
+	}
+	if !plan.AccountLevel && len(plan.Apps) == 0 {
+		problems = append(problems, "plan: there is nothing to migrate. Set accountLevel or add apps")
+	}
+
+	seen := make(map[string]bool)
+	for i, app := range plan.Apps {
+		prefix := fmt.Sprintf("apps[%d]", i)
+		if len(app.App) == 0 {
+			problems = append(problems, prefix+": app is required")
+		} else if seen[app.App] {
+			problems = append(problems, fmt.Sprintf("%s: app %s is listed more than once", prefix, app.App))
+		}
+		seen[app.App] = true
+		if len(getOrDefault(app.Org, plan.Org)) == 0 {
+			problems = append(problems, prefix+": org is required")
+		}
+		if len(getOrDefault(app.Project, plan.Project)) == 0 {
+			problems = append(problems, prefix+": project is required")
+		}
+		validateScopes(prefix, app.Scopes)
+		validateFile(prefix, "overrides", app.Overrides)
+		validateFile(prefix, "custom expressions", app.CustomExpressions)
+	}
+	return
+}
+
+// getPlanSteps orders the plan. Account level entities are migrated first followed by every app, its
+// workflows, pipelines & triggers.
+func getPlanSteps(plan MigrationPlan) (steps []PlanStep) {
+	if plan.AccountLevel {
+		for _, step := range []struct {
+			name       string
+			entityType EntityType
+		}{{"secret managers", SecretManager}, {"secrets", Secret}, {"connectors", Connector}} {
+			steps = append(steps, PlanStep{
+				Name:              "Import all " + step.name,
+				EntityType:        step.entityType,
+				Filter:            Filter{Type: All},
+				Org:               plan.Org,
+				Project:           plan.Project,
+				Scopes:            mergeScopes(plan.Scopes, PlanScopes{}),
+				Overrides:         plan.Overrides,
+				CustomExpressions: plan.CustomExpressions,
+			})
+		}
+	}
+	for _, app := range plan.Apps {
+		base := PlanStep{
+			Group:             app.App,
+			Org:               getOrDefault(app.Org, plan.Org),
+			Project:           getOrDefault(app.Project, plan.Project),
+			Scopes:            mergeScopes(plan.Scopes, app.Scopes),
+			Overrides:         getOrDefault(app.Overrides, plan.Overrides),
+			CustomExpressions: getOrDefault(app.CustomExpressions, plan.CustomExpressions),
+		}
+		step := base
+		step.Name = fmt.Sprintf("Import the app %s", app.App)
+		step.EntityType = Application
+		step.Filter = Filter{AppId: app.App}
+		steps = append(steps, step)
+		if !app.Workflows.isEmpty() {
+			step = base
+			step.Name = fmt.Sprintf("Import %s workflows of the app %s", describeSelection(app.Workflows), app.App)
+			step.EntityType = Workflow
+			step.Filter = Filter{AppId: app.App, WorkflowIds: app.Workflows.Ids}
+			steps = append(steps, step)
+		}
+		if !app.Pipelines.isEmpty() {
+			step = base
+			step.Name = fmt.Sprintf("Import %s pipelines of the app %s", describeSelection(app.Pipelines), app.App)
+			step.EntityType = Pipeline
+			step.Filter = Filter{AppId: app.App, PipelineIds: app.Pipelines.Ids}
+			steps = append(steps, step)
+		}
+		if !app.Triggers.isEmpty() {
+			step = base
+			step.Name = fmt.Sprintf("Import %s triggers of the app %s", describeSelection(app.Triggers), app.App)
+			step.EntityType = Trigger
+			step.Filter = Filter{AppId: app.App, TriggerIds: app.Triggers.Ids}
+			steps = append(steps, step)
+		}
+	}
+	return
+}
+
+func mergeScopes(defaults PlanScopes, s PlanScopes) PlanScopes {
+	return PlanScopes{
+		Secret:    getOrDefault(s.Secret, getOrDefault(defaults.Secret, Project)),
+		Connector: getOrDefault(s.Connector, getOrDefault(defaults.Connector, Project)),
+		Template:  getOrDefault(s.Template, getOrDefault(defaults.Template, Project)),
+		Workflow:  getOrDefault(s.Workflow, getOrDefault(defaults.Workflow, Project)),
+	}
+}
+
+func describeSelection(s EntitySelect) string {
+	if s.All {
+		return "all"
+	}
+	return strconv.Itoa(len(s.Ids))
+}
+
+func renderPlanTable(header table.Row, rows []table.Row) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(header)
+	t.AppendRows(rows)
+	t.SetStyle(table.StyleLight)
+	t.Render()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/jszwec/csvutil"
@@ -12,16 +13,28 @@ import (
 	"strconv"
 )
 
-func createProject(*cli.Context) error {
-	promptConfirm := PromptEnvDetails()
-	promptConfirm = PromptOrgAndProject([]string{Org}) || promptConfirm
+func createProject(ctx *cli.Context) error {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	promptConfirm, err = PromptOrgAndProject([]string{Org}, promptConfirm)
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.ProjectName) == 0 {
 		promptConfirm = true
-		migrationReq.ProjectName = TextInput("Name of the Project - ")
+		migrationReq.ProjectName, err = TextInput("Name of the Project - ")
+		if err != nil {
+			return err
+		}
 	}
 	if len(migrationReq.ProjectIdentifier) == 0 {
 		promptConfirm = true
-		migrationReq.ProjectIdentifier = TextInput("Identifier for the Project - ")
+		migrationReq.ProjectIdentifier, err = TextInput("Identifier for the Project - ")
+		if err != nil {
+			return err
+		}
 	}
 
 	log.WithFields(log.Fields{
@@ -34,13 +47,13 @@ func createProject(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with project creation?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	log.Info("Creating the project....")
 
-	err := createAProject(migrationReq.OrgIdentifier, migrationReq.ProjectName, migrationReq.ProjectIdentifier)
+	err = createAProject(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectName, migrationReq.ProjectIdentifier)
 
 	if err == nil {
 		log.Info("Created the project!")
@@ -52,9 +65,13 @@ func createProject(*cli.Context) error {
 	return nil
 }
 
-func createAProject(orgIdentifier string, name string, identifier string) error {
-	url := fmt.Sprintf("%s/api/projects?accountIdentifier=%s&orgIdentifier=%s", GetBaseUrl(migrationReq.Environment, NextGenService), migrationReq.Account, orgIdentifier)
-	_, err := Post(url, migrationReq.Auth, ProjectBody{
+func createAProject(ctx context.Context, orgIdentifier string, name string, identifier string) error {
+	baseUrl, err := GetBaseUrl(migrationReq.Environment, NextGenService)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/projects?accountIdentifier=%s&orgIdentifier=%s", baseUrl, migrationReq.Account, orgIdentifier)
+	_, err = Post(ctx, url, migrationReq.Auth, ProjectBody{
 		Project: ProjectDetails{
 			OrgIdentifier: orgIdentifier,
 			Identifier:    identifier,
@@ -66,17 +83,26 @@ func createAProject(orgIdentifier string, name string, identifier string) error
 	return err
 }
 
-func bulkCreateProject(*cli.Context) error {
-	promptConfirm := PromptDefaultInputs()
+func bulkCreateProject(ctx *cli.Context) error {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 
 	if len(migrationReq.CsvFile) != 0 {
 		fmt.Printf("Importing from - %s\n", migrationReq.CsvFile)
 	} else {
-		promptConfirm = PromptOrgAndProject([]string{Org}) || promptConfirm
+		promptConfirm, err = PromptOrgAndProject([]string{Org}, promptConfirm)
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.ExportFolderPath) == 0 {
-		migrationReq.ExportFolderPath = TextInput("Where would you like to export the generated files?")
+		migrationReq.ExportFolderPath, err = TextInput("Where would you like to export the generated files?")
+		if err != nil {
+			return err
+		}
 		promptConfirm = true
 	}
 
@@ -89,21 +115,24 @@ func bulkCreateProject(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with projects creation?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	if len(migrationReq.CsvFile) != 0 {
-		return CreateProjectsUsingCSV()
+		return CreateProjectsUsingCSV(ctx.Context)
 	}
 
-	url := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "projects/bulk", map[string]string{
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "projects/bulk", map[string]string{
 		AccountIdentifier: migrationReq.Account,
 	})
+	if err != nil {
+		return err
+	}
 
 	log.Info("Creating the projects....")
 
-	resp, err := Post(url, migrationReq.Auth, BulkCreateBody{
+	resp, err := Post(ctx.Context, url, migrationReq.Auth, BulkCreateBody{
 		DestinationAccountIdentifier: migrationReq.TargetAccount,
 		DestinationAuthToken:         migrationReq.TargetAuthToken,
 		DestinationGatewayUrl:        migrationReq.TargetGatewayUrl,
@@ -112,8 +141,7 @@ func bulkCreateProject(*cli.Context) error {
 	})
 
 	if err != nil {
-		log.Fatal("There was an error creating the projects", err)
-		return err
+		return fmt.Errorf("there was an error creating the projects: %w", err)
 	}
 
 	byteData, err := json.Marshal(resp.Resource)
@@ -133,7 +161,6 @@ func bulkCreateProject(*cli.Context) error {
 		}
 		err = writeYamlToFile(result.AppId, result.AppName, migrationReq.OrgIdentifier, result.ProjectIdentifier)
 		if err != nil {
-			log.Fatal(err)
 			return err
 		}
 	}
@@ -157,7 +184,6 @@ func writeYamlToFile(appId string, appName string, orgIdentifier string, project
 
 	yamlContent, err := yaml.Marshal(&yamlData)
 	if err != nil {
-		log.Fatalf("error: %v", err)
 		return err
 	}
 
@@ -171,23 +197,28 @@ func writeYamlToFile(appId string, appName string, orgIdentifier string, project
 	}
 	err = WriteToFile(path.Join(absolutePath, projectIdentifier+".yaml"), yamlContent)
 	if err != nil {
-		log.Fatal(err)
 		return err
 	}
 	log.Infof("Application %s was exported to file %s.yaml", appName, projectIdentifier)
 	return nil
 }
 
-func bulkRemoveProject(*cli.Context) error {
-	promptConfirm := PromptEnvDetails()
-	promptConfirm = PromptOrgAndProject([]string{Org}) || promptConfirm
+func bulkRemoveProject(ctx *cli.Context) error {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	promptConfirm, err = PromptOrgAndProject([]string{Org}, promptConfirm)
+	if err != nil {
+		return err
+	}
 	names := Split(migrationReq.Names, ",")
 	identifiers := Split(migrationReq.Identifiers, ",")
 	if len(names) == 0 && len(identifiers) == 0 {
-		log.Fatal("No names or identifiers for the projects provided. Aborting")
+		return &ValidationError{Message: "No names or identifiers for the projects provided. Aborting"}
 	}
 	if len(names) > 0 && len(identifiers) > 0 {
-		log.Fatal("Both names and identifiers for the projects provided. Aborting")
+		return &ValidationError{Message: "Both names and identifiers for the projects provided. Aborting"}
 	}
 
 	n := len(identifiers)
@@ -197,12 +228,15 @@ func bulkRemoveProject(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Are you sure you want to proceed with deletion of " + strconv.Itoa(n) + " projects?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	if len(names) > 0 {
-		projects := getProjects()
+		projects, err := getProjects(ctx.Context)
+		if err != nil {
+			return err
+		}
 		for _, name := range names {
 			id := findProjectIdByName(projects, name)
 			if len(id) > 0 {
@@ -212,48 +246,60 @@ func bulkRemoveProject(*cli.Context) error {
 		log.Debugf("Valid identifiers for the given names are - %s", identifiers)
 	}
 
-	for _, identifier := range identifiers {
-		deleteProject(identifier)
-	}
+	forEachConcurrently(ctx.Context, identifiers, migrationReq.Concurrency, func(identifier string) {
+		deleteProject(ctx.Context, identifier)
+	})
 	log.Info("Finished operation for all given projects")
 	return nil
 }
 
-func deleteProject(projectId string) {
-	url := fmt.Sprintf("%s/api/projects/%s?accountIdentifier=%s&orgIdentifier=%s", GetBaseUrl(migrationReq.Environment, NextGenService), projectId, migrationReq.Account, migrationReq.OrgIdentifier)
+func deleteProject(ctx context.Context, projectId string) {
+	baseUrl, err := GetBaseUrl(migrationReq.Environment, NextGenService)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to delete the project - %s", projectId)
+		return
+	}
+	url := fmt.Sprintf("%s/api/projects/%s?accountIdentifier=%s&orgIdentifier=%s", baseUrl, projectId, migrationReq.Account, migrationReq.OrgIdentifier)
 
 	log.Infof("Deleting the project with identifier %s", projectId)
 
-	_, err := Delete(url, migrationReq.Auth, nil)
+	_, err = Delete(ctx, url, migrationReq.Auth, nil)
 
 	if err == nil {
 		log.Infof("Successfully deleted the project - %s", projectId)
 	} else {
-		log.Errorf("Failed to delete the project - %s", projectId)
+		log.WithError(err).Errorf("Failed to delete the project - %s", projectId)
 	}
 }
 
-func getProjects() []ProjectDetails {
-	url := fmt.Sprintf("%s/api/projects?accountIdentifier=%s&orgIdentifier=%s&pageSize=1000", GetBaseUrl(migrationReq.Environment, NextGenService), migrationReq.Account, migrationReq.OrgIdentifier)
-	resp, err := Get(url, migrationReq.Auth)
-	if err != nil || resp.Status != "SUCCESS" {
-		log.Fatal("Failed to fetch projects", err)
+func getProjects(ctx context.Context) ([]ProjectDetails, error) {
+	baseUrl, err := GetBaseUrl(migrationReq.Environment, NextGenService)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/projects?accountIdentifier=%s&orgIdentifier=%s&pageSize=1000", baseUrl, migrationReq.Account, migrationReq.OrgIdentifier)
+	resp, err := Get(ctx, url, migrationReq.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+	if resp.Status != "SUCCESS" {
+		return nil, &APIError{StatusCode: 200, Url: url, Message: resp.Message, Messages: resp.Messages}
 	}
 	byteData, err := json.Marshal(resp.Data)
 	if err != nil {
-		log.Fatal("Failed to fetch projects", err)
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
 	}
 	var projects ProjectListBody
 	err = json.Unmarshal(byteData, &projects)
 	if err != nil {
-		log.Fatal("Failed to fetch projects", err)
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
 	}
 	var projectDetails []ProjectDetails
 
 	for _, p := range projects.Projects {
 		projectDetails = append(projectDetails, p.Project)
 	}
-	return projectDetails
+	return projectDetails, nil
 }
 
 func findProjectIdByName(projects []ProjectDetails, projectName string) string {
@@ -265,14 +311,19 @@ func findProjectIdByName(projects []ProjectDetails, projectName string) string {
 	return ""
 }
 
-func GetProjectCSVTemplate(*cli.Context) (err error) {
-	_ = PromptEnvDetails()
+func GetProjectCSVTemplate(ctx *cli.Context) (err error) {
+	if _, err := PromptEnvDetails(); err != nil {
+		return err
+	}
 
 	if len(migrationReq.CsvFile) == 0 {
-		migrationReq.CsvFile = TextInput("File to export the csv to - ")
+		migrationReq.CsvFile, err = TextInput("File to export the csv to - ")
+		if err != nil {
+			return err
+		}
 	}
 
-	apps, err := listEntities("apps")
+	apps, err := listEntities(ctx.Context, "apps")
 	if err != nil {
 		return
 	}
@@ -302,7 +353,7 @@ func GetProjectCSVTemplate(*cli.Context) (err error) {
 	return nil
 }
 
-func CreateProjectsUsingCSV() (err error) {
+func CreateProjectsUsingCSV(ctx context.Context) (err error) {
 	data, err := ReadFile(migrationReq.CsvFile)
 	if err != nil {
 		return
@@ -313,7 +364,7 @@ func CreateProjectsUsingCSV() (err error) {
 		return
 	}
 
-	apps, err := listEntities("apps")
+	apps, err := listEntities(ctx, "apps")
 	if err != nil {
 		return err
 	}
@@ -327,7 +378,7 @@ func CreateProjectsUsingCSV() (err error) {
 		if !ok {
 			continue
 		}
-		err = createAProject(record.OrgIdentifier, record.ProjectName, record.ProjectIdentifier)
+		err = createAProject(ctx, record.OrgIdentifier, record.ProjectName, record.ProjectIdentifier)
 		if err != nil {
 			log.Error(err)
 			continue
@@ -10,57 +10,76 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func PromptDefaultInputs() bool {
-	promptConfirm := PromptEnvDetails()
+func PromptDefaultInputs() (bool, error) {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return false, err
+	}
 
 	if len(migrationReq.SecretScope) == 0 {
 		promptConfirm = true
-		migrationReq.SecretScope = SelectInput("Scope for secrets & secret managers:", scopes, Project)
+		if migrationReq.SecretScope, err = SelectInput("Scope for secrets & secret managers:", scopes, Project); err != nil {
+			return false, err
+		}
 	}
 
 	if len(migrationReq.ConnectorScope) == 0 {
 		promptConfirm = true
-		migrationReq.ConnectorScope = SelectInput("Scope for connectors:", scopes, Project)
+		if migrationReq.ConnectorScope, err = SelectInput("Scope for connectors:", scopes, Project); err != nil {
+			return false, err
+		}
 	}
 
 	if len(migrationReq.TemplateScope) == 0 {
 		promptConfirm = true
-		migrationReq.TemplateScope = SelectInput("Scope for templates:", scopes, Project)
+		if migrationReq.TemplateScope, err = SelectInput("Scope for templates:", scopes, Project); err != nil {
+			return false, err
+		}
 	}
 
-	return promptConfirm
+	return promptConfirm, nil
 }
 
-func PromptSecretDetails() (promptConfirm bool) {
-	promptConfirm = PromptEnvDetails()
+func PromptSecretDetails() (promptConfirm bool, err error) {
+	promptConfirm, err = PromptEnvDetails()
+	if err != nil {
+		return
+	}
 	if len(migrationReq.SecretScope) == 0 {
 		promptConfirm = true
-		migrationReq.SecretScope = SelectInput("Scope for secrets & secret managers:", scopes, Project)
+		migrationReq.SecretScope, err = SelectInput("Scope for secrets & secret managers:", scopes, Project)
 	}
 	return
 }
 
-func PromptConnectorDetails() (promptConfirm bool) {
-	promptConfirm = PromptEnvDetails()
-	promptConfirm = PromptSecretDetails()
+func PromptConnectorDetails() (promptConfirm bool, err error) {
+	promptConfirm, err = PromptSecretDetails()
+	if err != nil {
+		return
+	}
 	if len(migrationReq.ConnectorScope) == 0 {
 		promptConfirm = true
-		migrationReq.ConnectorScope = SelectInput("Scope for connectors:", scopes, Project)
+		migrationReq.ConnectorScope, err = SelectInput("Scope for connectors:", scopes, Project)
 	}
 	return
 }
 
-func PromptEnvDetails() bool {
+func PromptEnvDetails() (bool, error) {
 	promptConfirm := false
+	var err error
 
 	if len(migrationReq.Environment) == 0 {
 		promptConfirm = true
-		migrationReq.Environment = SelectInput("Which environment?", []string{Dev, QA, Prod, Prod3}, Dev)
+		if migrationReq.Environment, err = SelectInput("Which environment?", []string{Dev, QA, Prod, Prod3}, Dev); err != nil {
+			return false, err
+		}
 	}
 
 	// Check if auth is provided. If not provided then request for one
 	if len(migrationReq.Auth) == 0 {
-		migrationReq.Auth = TextInput("The environment variable 'HARNESS_MIGRATOR_AUTH' is not set. What is the api key?")
+		if migrationReq.Auth, err = TextInput("The environment variable 'HARNESS_MIGRATOR_AUTH' is not set. What is the api key?"); err != nil {
+			return false, err
+		}
 	}
 
 	if len(migrationReq.UrlNG) != 0 && len(migrationReq.UrlCG) != 0 {
@@ -74,25 +93,33 @@ func PromptEnvDetails() bool {
 
 	if len(migrationReq.Account) == 0 {
 		promptConfirm = true
-		migrationReq.Account = TextInput("Account that you wish to migrate:")
+		if migrationReq.Account, err = TextInput("Account that you wish to migrate:"); err != nil {
+			return false, err
+		}
 	}
-	return promptConfirm
+	return promptConfirm, nil
 }
 
-func PromptOrgAndProject(scope []string) bool {
-	promptConfirm := false
+// PromptOrgAndProject asks for the org & project the scopes need. It returns true if it asked for any of them or if
+// promptConfirm is already set.
+func PromptOrgAndProject(scope []string, promptConfirm bool) (bool, error) {
 	promptOrg := len(migrationReq.OrgIdentifier) == 0 && ContainsAny(scope, []string{Org, Project})
 	promptProject := len(migrationReq.ProjectIdentifier) == 0 && ContainsAny(scope, []string{Project})
 
+	var err error
 	if promptOrg {
 		promptConfirm = true
-		migrationReq.OrgIdentifier = TextInput("Which Org?")
+		if migrationReq.OrgIdentifier, err = TextInput("Which Org?"); err != nil {
+			return false, err
+		}
 	}
 	if promptProject {
 		promptConfirm = true
-		migrationReq.ProjectIdentifier = TextInput("Which Project?")
+		if migrationReq.ProjectIdentifier, err = TextInput("Which Project?"); err != nil {
+			return false, err
+		}
 	}
-	return promptConfirm
+	return promptConfirm, nil
 }
 
 func ParseNGUrl() {
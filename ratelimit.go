@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var services = []string{MigratorService, NextGenService, TemplateService, PipelineService}
+
+// RateLimiter paces the requests made to a service
+type RateLimiter interface {
+	// Wait blocks until a request can be made or the context is done
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket allows bursts of up to burst requests & refills at rate requests per second
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		if err := sleepWithContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// rateLimiters holds the limiter of every service. Requests to services without one are not limited.
+var rateLimiters = struct {
+	sync.RWMutex
+	limiters map[string]RateLimiter
+}{limiters: make(map[string]RateLimiter)}
+
+func SetRateLimiter(service string, limiter RateLimiter) {
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+	if limiter == nil {
+		delete(rateLimiters.limiters, service)
+		return
+	}
+	rateLimiters.limiters[service] = limiter
+}
+
+// initRateLimiters creates the limiters from the --rate-limit & --service-rate-limits flags
+func initRateLimiters() error {
+	limits := make(map[string]float64)
+	if migrationReq.RateLimit > 0 {
+		for _, service := range services {
+			limits[service] = migrationReq.RateLimit
+		}
+	}
+	for _, entry := range Split(migrationReq.ServiceRateLimits, ",") {
+		service, value, found := strings.Cut(entry, "=")
+		service = strings.TrimSpace(service)
+		limit, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !found || err != nil || limit < 0 || !slices.Contains(services, service) {
+			return &ValidationError{Message: fmt.Sprintf("Invalid service rate limit %s. Use SERVICE=REQUESTS_PER_SECOND where SERVICE is one of %s", entry, strings.Join(services, ", "))}
+		}
+		limits[service] = limit
+	}
+	for service, limit := range limits {
+		if limit == 0 {
+			SetRateLimiter(service, nil)
+			continue
+		}
+		SetRateLimiter(service, NewTokenBucket(limit, int(math.Max(1, math.Ceil(limit)))))
+	}
+	return nil
+}
+
+// waitForRateLimit waits for the limiter of the service that the url belongs to
+func waitForRateLimit(ctx context.Context, reqUrl string) error {
+	rateLimiters.RLock()
+	if len(rateLimiters.limiters) == 0 {
+		rateLimiters.RUnlock()
+		return nil
+	}
+	limiter := rateLimiters.limiters[getServiceOfUrl(reqUrl)]
+	rateLimiters.RUnlock()
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
+
+// getServiceOfUrl finds the service with the longest base url that the url starts with. For self managed platforms
+// the base url of a service can be a prefix of another's.
+func getServiceOfUrl(reqUrl string) string {
+	var match string
+	var matchLen int
+	for _, service := range services {
+		baseUrl, err := GetBaseUrl(migrationReq.Environment, service)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(reqUrl, baseUrl) && len(baseUrl) > matchLen {
+			match, matchLen = service, len(baseUrl)
+		}
+	}
+	return match
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/fatih/color"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -81,12 +82,14 @@ func CheckGithubForReleases() {
 	}
 }
 
+// printUpgradeMessage writes the notice to stderr so that it does not mix with the output of commands like
+// expressions that write to stdout
 func printUpgradeMessage(from string, to string) {
 	blue := color.New(color.FgHiBlue).SprintFunc()
 	green := color.New(color.FgGreen).SprintFunc()
 	red := color.New(color.FgHiRed).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
-	fmt.Printf("[%s] A new release of harness-upgrade is available: %s → %s\n", blue("notice"), red(from), green(to))
-	fmt.Printf("%s\n", yellow("https://github.com/harness/migrator/releases/tag/"+to))
-	fmt.Printf("To update, run: %s\n", green("harness-upgrade update"))
+	fmt.Fprintf(os.Stderr, "[%s] A new release of harness-upgrade is available: %s → %s\n", blue("notice"), red(from), green(to))
+	fmt.Fprintf(os.Stderr, "%s\n", yellow("https://github.com/harness/migrator/releases/tag/"+to))
+	fmt.Fprintf(os.Stderr, "To update, run: %s\n", green("harness-upgrade update"))
 }
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jszwec/csvutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported formats of the migration report
+const (
+	JsonReport     = "json"
+	CsvReport      = "csv"
+	JUnitReport    = "junit"
+	MarkdownReport = "markdown"
+)
+
+var reportFormats = []string{JsonReport, CsvReport, JUnitReport, MarkdownReport}
+
+// MigrationReport holds the save summaries of all the requests made during a single run of the CLI
+type MigrationReport struct {
+	RequestIds []string `json:"requestIds"`
+	SaveSummary
+}
+
+type ReportRow struct {
+	Outcome     string `csv:"outcome"`
+	Type        string `csv:"type"`
+	AppId       string `csv:"appId"`
+	Id          string `csv:"id"`
+	Name        string `csv:"name"`
+	Org         string `csv:"org"`
+	Project     string `csv:"project"`
+	Message     string `csv:"message"`
+	Expressions string `csv:"expressions"`
+}
+
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type JUnitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+var report = struct {
+	sync.Mutex
+	MigrationReport
+}{}
+
+// recordSaveSummary adds the summary of a completed request to the report of the current run
+func recordSaveSummary(reqId string, summary SaveSummary) {
+	report.Lock()
+	defer report.Unlock()
+	report.RequestIds = append(report.RequestIds, reqId)
+	report.SaveSummary = mergeSaveSummaries(report.SaveSummary, summary)
+}
+
+func mergeSaveSummaries(a SaveSummary, b SaveSummary) SaveSummary {
+	stats := make(map[string]MigrationStats)
+	for _, summary := range []SaveSummary{a, b} {
+		for k, v := range summary.Stats {
+			s := stats[k]
+			s.SuccessfullyMigrated += v.SuccessfullyMigrated
+			s.AlreadyMigrated += v.AlreadyMigrated
+			stats[k] = s
+		}
+	}
+	return SaveSummary{
+		Stats:                  stats,
+		Errors:                 append(append([]UpgradeError{}, a.Errors...), b.Errors...),
+		SkipDetails:            append(append([]SkipDetail{}, a.SkipDetails...), b.SkipDetails...),
+		SkippedExpressionsList: append(append([]SkippedExpressionDetail{}, a.SkippedExpressionsList...), b.SkippedExpressionsList...),
+	}
+}
+
+func validateReportFormat() error {
+	if len(migrationReq.ReportFile) == 0 {
+		return nil
+	}
+	return assertAllowedValues(migrationReq.ReportFormat, reportFormats, fmt.Sprintf("Invalid report format %s. Possible values - %s", migrationReq.ReportFormat, strings.Join(reportFormats, ", ")))
+}
+
+// writeReport writes the report of the current run to the file provided using the --report flag
+func writeReport() error {
+	if len(migrationReq.ReportFile) == 0 {
+		return nil
+	}
+	report.Lock()
+	defer report.Unlock()
+	if len(report.RequestIds) == 0 {
+		log.Debug("No migration requests were completed. Skipping the report")
+		return nil
+	}
+
+	var content []byte
+	var err error
+	switch migrationReq.ReportFormat {
+	case CsvReport:
+		content, err = csvutil.Marshal(toReportRows(report.SaveSummary))
+	case JUnitReport:
+		content, err = toJUnit(report.MigrationReport)
+	case MarkdownReport:
+		content = []byte(toMarkdown(report.MigrationReport))
+	default:
+		content, err = json.MarshalIndent(report.MigrationReport, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	err = WriteToFile(migrationReq.ReportFile, content)
+	if err != nil {
+		return err
+	}
+	log.Infof("The migration report was written to %s", migrationReq.ReportFile)
+	return nil
+}
+
+func toReportRows(summary SaveSummary) []ReportRow {
+	var rows []ReportRow
+	for _, e := range summary.Errors {
+		outcome := "ERROR"
+		if isSkippableError(e.Message) {
+			outcome = "ALREADY_EXISTS"
+		}
+		rows = append(rows, ReportRow{Outcome: outcome, Type: e.Entity.Type, AppId: e.Entity.AppId, Id: e.Entity.Id, Name: e.Entity.Name, Message: e.Message})
+	}
+	for _, s := range summary.SkipDetails {
+		rows = append(rows, ReportRow{Outcome: "SKIPPED", Type: s.Entity.Type, AppId: s.Entity.AppId, Id: s.Entity.Id, Name: s.Entity.Name, Message: s.Reason})
+	}
+	for _, e := range summary.SkippedExpressionsList {
+		rows = append(rows, ReportRow{Outcome: "SKIPPED_EXPRESSIONS", Type: e.EntityType, Id: e.Identifier, Org: e.OrgIdentifier, Project: e.ProjectIdentifier, Expressions: strings.Join(e.Expressions, " ")})
+	}
+	return rows
+}
+
+func toJUnit(migrationReport MigrationReport) ([]byte, error) {
+	suites := make(map[string]*JUnitTestSuite)
+	getSuite := func(name string) *JUnitTestSuite {
+		if len(name) == 0 {
+			name = "UNKNOWN"
+		}
+		if _, ok := suites[name]; !ok {
+			suites[name] = &JUnitTestSuite{Name: name}
+		}
+		return suites[name]
+	}
+
+	for k, v := range migrationReport.Stats {
+		suite := getSuite(k)
+		suite.TestCases = append(suite.TestCases, JUnitTestCase{
+			Name:      k + " migration",
+			ClassName: k,
+			SystemOut: fmt.Sprintf("Successfully migrated - %d, Already migrated - %d", v.SuccessfullyMigrated, v.AlreadyMigrated),
+		})
+	}
+	for _, e := range migrationReport.Errors {
+		suite := getSuite(e.Entity.Type)
+		testCase := JUnitTestCase{Name: entityTestCaseName(e.Entity), ClassName: suite.Name}
+		if isSkippableError(e.Message) {
+			testCase.Skipped = &JUnitMessage{Message: e.Message}
+		} else {
+			testCase.Failure = &JUnitMessage{Message: e.Message, Type: "error"}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	for _, s := range migrationReport.SkipDetails {
+		suite := getSuite(s.Entity.Type)
+		suite.TestCases = append(suite.TestCases, JUnitTestCase{
+			Name:      entityTestCaseName(s.Entity),
+			ClassName: suite.Name,
+			Failure:   &JUnitMessage{Message: s.Reason, Type: "skipped"},
+		})
+	}
+	for _, e := range migrationReport.SkippedExpressionsList {
+		suite := getSuite(e.EntityType)
+		suite.TestCases = append(suite.TestCases, JUnitTestCase{
+			Name:      fmt.Sprintf("%s expressions", e.Identifier),
+			ClassName: suite.Name,
+			Failure:   &JUnitMessage{Message: "Expressions were not migrated - " + strings.Join(e.Expressions, ", "), Type: "skippedExpressions"},
+		})
+	}
+
+	testSuites := JUnitTestSuites{Name: "harness-upgrade"}
+	var names []string
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		suite := suites[name]
+		for _, t := range suite.TestCases {
+			suite.Tests++
+			if t.Failure != nil {
+				suite.Failures++
+			}
+			if t.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		testSuites.Tests += suite.Tests
+		testSuites.Failures += suite.Failures
+		testSuites.Skipped += suite.Skipped
+		testSuites.Suites = append(testSuites.Suites, *suite)
+	}
+	content, err := xml.MarshalIndent(testSuites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
+
+func entityTestCaseName(entity CurrentGenEntity) string {
+	if len(entity.Name) > 0 {
+		return fmt.Sprintf("%s (%s)", entity.Name, entity.Id)
+	}
+	if len(entity.Id) > 0 {
+		return entity.Id
+	}
+	return "migration"
+}
+
+func toMarkdown(migrationReport MigrationReport) string {
+	var sb strings.Builder
+	sb.WriteString("# Migration Report\n\n")
+	sb.WriteString(fmt.Sprintf("Request IDs - %s\n\n", strings.Join(migrationReport.RequestIds, ", ")))
+
+	if len(migrationReport.Stats) > 0 {
+		sb.WriteString("## Summary\n\n| Type | Successfully Migrated | Already Migrated |\n|---|---|---|\n")
+		var types []string
+		for k := range migrationReport.Stats {
+			types = append(types, k)
+		}
+		sort.Strings(types)
+		for _, k := range types {
+			v := migrationReport.Stats[k]
+			sb.WriteString(fmt.Sprintf("| %s | %d | %d |\n", k, v.SuccessfullyMigrated, v.AlreadyMigrated))
+		}
+		sb.WriteString("\n")
+	}
+
+	rows := toReportRows(migrationReport.SaveSummary)
+	sections := []struct {
+		title   string
+		outcome string
+	}{
+		{"Errors", "ERROR"},
+		{"Skipped", "SKIPPED"},
+		{"Already Exists", "ALREADY_EXISTS"},
+	}
+	for _, section := range sections {
+		var lines []string
+		for _, r := range rows {
+			if r.Outcome == section.outcome {
+				lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s | %s |", r.Type, r.AppId, r.Id, escapeMarkdown(r.Name), escapeMarkdown(r.Message)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("## %s\n\n| Type | App ID | ID | Name | Message |\n|---|---|---|---|---|\n", section.title))
+		sb.WriteString(strings.Join(lines, "\n"))
+		sb.WriteString("\n\n")
+	}
+
+	if len(migrationReport.SkippedExpressionsList) > 0 {
+		sb.WriteString("## Skipped Expressions\n\n| Type | Identifier | Org | Project | Expressions |\n|---|---|---|---|---|\n")
+		for _, e := range migrationReport.SkippedExpressionsList {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", e.EntityType, e.Identifier, e.OrgIdentifier, e.ProjectIdentifier, escapeMarkdown(strings.Join(e.Expressions, ", "))))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func escapeMarkdown(str string) string {
+	str = strings.ReplaceAll(str, "|", "\\|")
+	return strings.ReplaceAll(str, "\n", " ")
+}
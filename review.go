@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	log "github.com/sirupsen/logrus"
+)
+
+// Decisions taken when reviewing an expression
+const (
+	AcceptExpression = "ACCEPT"
+	SkipExpression   = "SKIP"
+	EditExpression   = "EDIT"
+)
+
+// reviewContext is the number of lines shown before & after the line of the expression being reviewed
+const reviewContext = 2
+
+// Options of the prompt shown for every expression
+const (
+	acceptOption    = "Accept"
+	acceptAllOption = "Accept for all identical expressions"
+	editOption      = "Edit the replacement"
+	editAllOption   = "Edit the replacement for all identical expressions"
+	skipOption      = "Skip"
+	skipAllOption   = "Skip all identical expressions"
+)
+
+type ExpressionAnswer struct {
+	Decision    string `json:"decision"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// ExpressionAnswers are the decisions taken when reviewing expressions, remembered across runs
+type ExpressionAnswers struct {
+	// Occurrences holds the decisions for single occurrences keyed by file, line, column & expression
+	Occurrences map[string]ExpressionAnswer `json:"occurrences"`
+	// All holds the decisions for all the occurrences of an expression
+	All map[string]ExpressionAnswer `json:"all"`
+}
+
+func getAnswersFile() string {
+	return filepath.Join(StateDir, "expression-answers.json")
+}
+
+// newInteractiveReviewer asks what to do with every expression that was not answered before. Answers of previous runs
+// are forgotten with --fresh.
+func newInteractiveReviewer() (expressionReviewer, error) {
+	answers := ExpressionAnswers{Occurrences: make(map[string]ExpressionAnswer), All: make(map[string]ExpressionAnswer)}
+	if !migrationReq.Fresh {
+		content, err := os.ReadFile(getAnswersFile())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read the answers: %w", err)
+		}
+		if err == nil {
+			if err = json.Unmarshal(content, &answers); err != nil {
+				return nil, fmt.Errorf("failed to read the answers from %s: %w", getAnswersFile(), err)
+			}
+			log.Infof("Using the answers of previous runs from %s. Pass --fresh to review all the expressions again", getAnswersFile())
+		}
+	}
+
+	return func(filePath string, content string, e *Expression, proposed string, ok bool) (string, bool, error) {
+		key := fmt.Sprintf("%s:%d:%d:%s", filepath.ToSlash(filePath), e.Start.Line, e.Start.Column, e.Raw)
+		answer, found := answers.Occurrences[key]
+		if !found {
+			answer, found = answers.All[e.Raw]
+		}
+		if !found {
+			var all bool
+			var err error
+			answer, all, err = askExpressionAnswer(filePath, content, e, proposed, ok)
+			if err != nil {
+				return "", false, err
+			}
+			if all {
+				answers.All[e.Raw] = answer
+			} else {
+				answers.Occurrences[key] = answer
+			}
+			if err := answers.save(); err != nil {
+				log.WithError(err).Warn("Failed to save the answers")
+			}
+		}
+		switch answer.Decision {
+		case AcceptExpression:
+			return proposed, ok, nil
+		case EditExpression:
+			return answer.Replacement, len(answer.Replacement) > 0, nil
+		default:
+			return "", false, nil
+		}
+	}, nil
+}
+
+func (a *ExpressionAnswers) save() error {
+	content, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = MkDir(StateDir); err != nil {
+		return err
+	}
+	return WriteFileAtomic(getAnswersFile(), content)
+}
+
+// askExpressionAnswer shows the expression with the lines around it & asks what to do with it. It also returns if
+// the answer is for all the identical expressions.
+func askExpressionAnswer(filePath string, content string, e *Expression, proposed string, ok bool) (ExpressionAnswer, bool, error) {
+	printExpressionContext(filePath, content, e)
+	options := []string{editOption, editAllOption, skipOption, skipAllOption}
+	question := fmt.Sprintf("No equivalent found for %s. What do you want to do?", e.Raw)
+	if ok {
+		options = append([]string{acceptOption, acceptAllOption}, options...)
+		question = fmt.Sprintf("Replace %s with %s?", e.Raw, proposed)
+	}
+	choice, err := SelectInput(question, options, options[0])
+	if err != nil {
+		return ExpressionAnswer{}, false, err
+	}
+	switch choice {
+	case acceptOption, acceptAllOption:
+		return ExpressionAnswer{Decision: AcceptExpression}, choice == acceptAllOption, nil
+	case editOption, editAllOption:
+		replacement, err := TextInputWithDefault("Replacement for "+e.Raw, proposed)
+		if err != nil {
+			return ExpressionAnswer{}, false, err
+		}
+		if len(strings.TrimSpace(replacement)) == 0 {
+			// An empty replacement would delete the expression from the file
+			log.Infof("No replacement given for %s. Skipping it", e.Raw)
+			return ExpressionAnswer{Decision: SkipExpression}, false, nil
+		}
+		return ExpressionAnswer{Decision: EditExpression, Replacement: replacement}, choice == editAllOption, nil
+	default:
+		return ExpressionAnswer{Decision: SkipExpression}, choice == skipAllOption, nil
+	}
+}
+
+func printExpressionContext(filePath string, content string, e *Expression) {
+	lines := strings.Split(content, "\n")
+	from := e.Start.Line - reviewContext
+	if from < 1 {
+		from = 1
+	}
+	to := e.Start.Line + reviewContext
+	if to > len(lines) {
+		to = len(lines)
+	}
+	fmt.Println()
+	color.New(color.Bold).Printf("%s:%d:%d\n", filePath, e.Start.Line, e.Start.Column)
+	for n := from; n <= to; n++ {
+		line := lines[n-1]
+		if n != e.Start.Line {
+			fmt.Printf("%5d | %s\n", n, line)
+			continue
+		}
+		start := e.Start.Column - 1
+		end := start + len(e.Raw)
+		fmt.Printf("%5d | %s%s%s\n", n, line[:start], color.New(color.FgYellow, color.Bold).Sprint(line[start:end]), line[end:])
+	}
+}
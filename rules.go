@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of the rules of the custom expressions file
+const (
+	ExactRule  = "exact"
+	PrefixRule = "prefix"
+	RegexRule  = "regex"
+)
+
+// MappingRule converts the expressions that match it. Exactly one of Exact, Prefix & Regex is set. Value is a
+// template where `$1` or `${name}` is replaced by the capture group of a regex & `$1` or `${rest}` by what follows
+// the prefix.
+type MappingRule struct {
+	Exact  string `yaml:"exact"`
+	Prefix string `yaml:"prefix"`
+	Regex  string `yaml:"regex"`
+	Value  string `yaml:"value"`
+
+	line int
+	re   *regexp.Regexp
+}
+
+// templateRefPattern matches the capture group references of a template, `$$` being an escaped `$`
+var templateRefPattern = regexp.MustCompile(`\$(\$|\{(\w+)}|(\w+))`)
+
+func (r *MappingRule) UnmarshalYAML(node *yaml.Node) error {
+	type plain MappingRule
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.line = node.Line
+	return nil
+}
+
+func (r *MappingRule) kind() (string, string) {
+	switch {
+	case len(r.Exact) > 0:
+		return ExactRule, r.Exact
+	case len(r.Prefix) > 0:
+		return PrefixRule, r.Prefix
+	default:
+		return RegexRule, r.Regex
+	}
+}
+
+// compile builds the regex of the rule. Prefixes may end with `*` for readability.
+func (r *MappingRule) compile() error {
+	var err error
+	switch kind, match := r.kind(); kind {
+	case ExactRule:
+		r.re, err = regexp.Compile("^" + regexp.QuoteMeta(match) + "$")
+	case PrefixRule:
+		r.re, err = regexp.Compile("^" + regexp.QuoteMeta(strings.TrimSuffix(match, "*")) + "(?P<rest>.*)$")
+	default:
+		r.re, err = regexp.Compile(match)
+	}
+	return err
+}
+
+// apply returns the value of the rule for the key if it matches
+func (r *MappingRule) apply(key string) (string, bool) {
+	if r.re == nil {
+		return "", false
+	}
+	match := r.re.FindStringSubmatchIndex(key)
+	if match == nil {
+		return "", false
+	}
+	return string(r.re.ExpandString(nil, r.Value, key, match)), true
+}
+
+// lint returns the problems of the rule. The rule is compiled if it has none.
+func (r *MappingRule) lint() []string {
+	var problems []string
+	kinds := 0
+	for _, match := range []string{r.Exact, r.Prefix, r.Regex} {
+		if len(match) > 0 {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		problems = append(problems, fmt.Sprintf("line %d: a rule needs exactly one of exact, prefix or regex but has %d", r.line, kinds))
+	}
+	if len(strings.TrimSpace(r.Value)) == 0 {
+		problems = append(problems, fmt.Sprintf("line %d: the rule has no value", r.line))
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	if err := r.compile(); err != nil {
+		return []string{fmt.Sprintf("line %d: invalid regex %s - %s", r.line, r.Regex, err)}
+	}
+	for _, ref := range templateRefPattern.FindAllStringSubmatch(r.Value, -1) {
+		name := ref[2] + ref[3]
+		if ref[1] == "$" || slices.Contains(r.re.SubexpNames(), name) {
+			continue
+		}
+		if n, err := strconv.Atoi(name); err == nil && n <= r.re.NumSubexp() {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("line %d: the value refers to %s but the rule has no such capture group", r.line, ref[0]))
+	}
+	if len(problems) > 0 {
+		r.re = nil
+	}
+	return problems
+}
+
+// applyRules returns the value of the first rule that matches the key
+func applyRules(rules []MappingRule, key string) (string, bool) {
+	for i := range rules {
+		if val, ok := rules[i].apply(key); ok {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// lintCustomExpressions returns all the problems of the custom expressions file
+func lintCustomExpressions(custom *CustomExpressions) []string {
+	problems := lintRules(custom.Rules)
+	for i := range custom.Scopes {
+		scope := &custom.Scopes[i]
+		if len(scope.Context) > 0 && !slices.Contains(expressionContexts, scope.Context) {
+			problems = append(problems, fmt.Sprintf("line %d: invalid context %s. Allowed values are %s", scope.line, scope.Context, strings.Join(expressionContexts, ", ")))
+		}
+		for _, pattern := range append(append([]string{}, scope.Files...), scope.KeyPaths...) {
+			if !isValidGlob(pattern) {
+				problems = append(problems, fmt.Sprintf("line %d: invalid pattern %s", scope.line, pattern))
+			}
+		}
+		if len(scope.Expressions) == 0 && len(scope.Rules) == 0 {
+			problems = append(problems, fmt.Sprintf("line %d: the scope has no expressions or rules", scope.line))
+		}
+		problems = append(problems, lintRules(scope.Rules)...)
+	}
+	return problems
+}
+
+func lintRules(rules []MappingRule) []string {
+	var problems []string
+	seen := make(map[string]int)
+	for i := range rules {
+		rule := &rules[i]
+		ruleProblems := rule.lint()
+		problems = append(problems, ruleProblems...)
+		if len(ruleProblems) > 0 {
+			continue
+		}
+		kind, match := rule.kind()
+		if line, ok := seen[kind+":"+match]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: the rule is never used as the rule on line %d has the same %s", rule.line, line, kind))
+			continue
+		}
+		seen[kind+":"+match] = rule.line
+	}
+	return problems
+}
+
+// ValidateExpressionRules lists the rules of the custom expressions file or lints it with --validate
+func ValidateExpressionRules(*cli.Context) error {
+	if len(migrationReq.CustomExpressionsFile) == 0 {
+		return &ValidationError{Message: "No custom expressions file provided. Use --custom-expressions to provide one"}
+	}
+	custom, problems, err := readCustomExpressions(migrationReq.CustomExpressionsFile)
+	if err != nil {
+		return err
+	}
+	if migrationReq.Validate {
+		for _, problem := range problems {
+			log.Error(problem)
+		}
+		if len(problems) > 0 {
+			return &ValidationError{Message: fmt.Sprintf("Found %d problems in %s", len(problems), migrationReq.CustomExpressionsFile)}
+		}
+		log.Infof("%s is valid", migrationReq.CustomExpressionsFile)
+		return nil
+	}
+	if len(problems) > 0 {
+		log.Warnf("Found %d problems in %s. Run with --validate to list them", len(problems), migrationReq.CustomExpressionsFile)
+	}
+	renderRulesTable(custom)
+	return nil
+}
+
+func renderRulesTable(custom CustomExpressions) {
+	var rows []table.Row
+	addRules := func(scope string, rules []MappingRule) {
+		for _, rule := range rules {
+			kind, match := rule.kind()
+			rows = append(rows, table.Row{rule.line, scope, kind, match, rule.Value})
+		}
+	}
+	for _, key := range sortedKeys(custom.Expressions) {
+		rows = append(rows, table.Row{"", "", ExactRule, key, custom.Expressions[key]})
+	}
+	addRules("", custom.Rules)
+	for _, scope := range custom.Scopes {
+		for _, key := range sortedKeys(scope.Expressions) {
+			rows = append(rows, table.Row{scope.line, scope.describe(), ExactRule, key, scope.Expressions[key]})
+		}
+		addRules(scope.describe(), scope.Rules)
+	}
+	if len(rows) == 0 {
+		log.Info("No expressions or rules found in the file")
+		return
+	}
+	renderPlanTable(table.Row{"Line", "Scope", "Type", "Match", "Value"}, rows)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// describe returns a short description of where the scope applies
+func (r ExpressionRule) describe() string {
+	var parts []string
+	if len(r.Context) > 0 {
+		parts = append(parts, "context "+r.Context)
+	}
+	if len(r.Files) > 0 {
+		parts = append(parts, "files "+strings.Join(r.Files, ", "))
+	}
+	if len(r.KeyPaths) > 0 {
+		parts = append(parts, "keys "+strings.Join(r.KeyPaths, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,15 +1,217 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
 
-func migrateSecrets(*cli.Context) (err error) {
-	promptConfirm := PromptSecretDetails()
-	err = MigrateEntities(promptConfirm, []string{migrationReq.SecretScope}, "secrets", Secret)
+// SecretReference is where a First Gen secret can be found in Next Gen
+type SecretReference struct {
+	Identifier string
+	Scope      string
+}
+
+// secretReferences are the references of the First Gen secrets keyed by their name. Secrets that are not found here
+// are referred to by the identifier generated from their name in the --secret-scope.
+var secretReferences = make(map[string]SecretReference)
+
+// secretRules are the override rules that apply to secrets
+var secretRules []OverrideRule
+
+func migrateSecrets(ctx *cli.Context) (err error) {
+	promptConfirm, err := PromptSecretDetails()
 	if err != nil {
-		log.Fatal("Failed to migrate secrets")
+		return err
+	}
+	err = MigrateEntities(ctx.Context, promptConfirm, []string{migrationReq.SecretScope}, "secrets", Secret)
+	if err != nil {
+		return fmt.Errorf("failed to migrate secrets: %w", err)
 	}
 	return
 }
+
+// loadSecretReferences finds where the secrets are in Next Gen from the secret overrides of the --override file &,
+// with --lookup-secrets, from the secrets that exist in Next Gen
+func loadSecretReferences(ctx context.Context) error {
+	names, err := loadSecretOverrides(migrationReq.OverrideFile)
+	if err != nil {
+		return err
+	}
+	if !migrationReq.LookupSecrets {
+		return nil
+	}
+
+	// Secrets are looked up in the project, then the org & then the account
+	found := make(map[string]map[string]string)
+	for _, scope := range scopes {
+		orgId, projectId := "", ""
+		if scope != Account {
+			orgId = migrationReq.OrgIdentifier
+		}
+		if scope == Project {
+			projectId = migrationReq.ProjectIdentifier
+		}
+		if (scope == Org && len(orgId) == 0) || (scope == Project && len(projectId) == 0) {
+			continue
+		}
+		secrets, err := getSecrets(ctx, orgId, projectId)
+		if err != nil {
+			return err
+		}
+		found[scope] = make(map[string]string)
+		for _, secret := range secrets {
+			found[scope][secret.Name] = secret.Identifier
+		}
+		log.Debugf("Found %d secrets in the %s", len(secrets), scope)
+	}
+
+	resolve := func(firstGenName string, name string, ref SecretReference) {
+		if id, ok := found[ref.Scope][name]; ok {
+			secretReferences[firstGenName] = SecretReference{Identifier: id, Scope: ref.Scope}
+			return
+		}
+		for _, scope := range scopes {
+			if id, ok := found[scope][name]; ok {
+				secretReferences[firstGenName] = SecretReference{Identifier: id, Scope: scope}
+				return
+			}
+		}
+	}
+	for firstGenName, name := range names {
+		resolve(firstGenName, name, secretReferences[firstGenName])
+	}
+	// Secrets without overrides keep their name
+	for scope := range found {
+		for name := range found[scope] {
+			if _, ok := names[name]; !ok {
+				resolve(name, name, SecretReference{Scope: migrationReq.SecretScope})
+			}
+		}
+	}
+	return nil
+}
+
+// loadSecretOverrides adds the references of the secrets overridden by their First Gen name & keeps the override rules
+// of secrets. It returns the Next Gen names of the overridden secrets.
+func loadSecretOverrides(filePath string) (map[string]string, error) {
+	names := make(map[string]string)
+	filePath = strings.TrimSpace(filePath)
+	if len(filePath) == 0 {
+		return names, nil
+	}
+	data, problems, err := readOverrides(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Message: fmt.Sprintf("invalid overrides file %s - %s", filePath, strings.Join(problems, "; "))}
+	}
+	for _, override := range data.Overrides {
+		if override.Type != Secret {
+			continue
+		}
+		if len(strings.TrimSpace(override.FirstGenName)) == 0 {
+			log.Debugf("Skipping the override of the secret %s as secrets are referred to by name in expressions", override.ID)
+			continue
+		}
+		ref := getSecretReferenceOf(override)
+		secretReferences[override.FirstGenName] = ref
+		names[override.FirstGenName] = getOrDefault(getValue(override.Name), override.FirstGenName)
+	}
+	for _, rule := range data.Rules {
+		if len(rule.Type) == 0 || rule.Type == Secret {
+			secretRules = append(secretRules, rule)
+		}
+	}
+	log.Debugf("Loaded the references of %d overridden secrets & %d override rules of secrets", len(names), len(secretRules))
+	return names, nil
+}
+
+// getSecretReferenceOf returns where the overridden secret is in Next Gen
+func getSecretReferenceOf(override EntityOverride) SecretReference {
+	name := getOrDefault(getValue(override.Name), override.FirstGenName)
+	return SecretReference{
+		Identifier: getOrDefault(getValue(override.Identifier), ToIdentifier(name)),
+		Scope:      getOrDefault(getValue(override.Scope), migrationReq.SecretScope),
+	}
+}
+
+func getValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// getSecretReference returns the reference to a secret in an expression, which is prefixed by its scope unless the
+// secret is in the project
+func getSecretReference(firstGenName string) string {
+	ref, ok := secretReferences[firstGenName]
+	if !ok {
+		ref = SecretReference{Identifier: ToIdentifier(firstGenName), Scope: migrationReq.SecretScope}
+		override, matched, err := applyOverrideRules(secretRules, Secret, BaseEntityDetail{Name: firstGenName})
+		if err != nil {
+			log.WithError(err).Warnf("Failed to apply the override rules to the secret %s", firstGenName)
+		} else if matched {
+			ref = getSecretReferenceOf(override)
+		}
+	}
+	switch ref.Scope {
+	case Account:
+		return Account + "." + ref.Identifier
+	case Org:
+		return Org + "." + ref.Identifier
+	default:
+		return ref.Identifier
+	}
+}
+
+// getSecrets fetches the secrets of the scope page by page until all of them are fetched
+func getSecrets(ctx context.Context, orgId string, projectId string) ([]SecretDetails, error) {
+	queryParams := map[string]string{
+		AccountIdentifier: migrationReq.Account,
+		"pageSize":        "1000",
+	}
+	if len(orgId) > 0 {
+		queryParams[OrgIdentifier] = orgId
+	}
+	if len(projectId) > 0 {
+		queryParams[ProjectIdentifier] = projectId
+	}
+	var secrets []SecretDetails
+	for page := 0; ; page++ {
+		queryParams["pageIndex"] = strconv.Itoa(page)
+		url, err := GetUrlWithQueryParams(migrationReq.Environment, NextGenService, "api/v2/secrets", queryParams)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := Get(ctx, url, migrationReq.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+		}
+		if resp.Status != "SUCCESS" {
+			return nil, &APIError{StatusCode: 200, Url: url, Message: resp.Message, Messages: resp.Messages}
+		}
+		byteData, err := json.Marshal(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+		}
+		var secretListBody SecretListBody
+		err = json.Unmarshal(byteData, &secretListBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+		}
+		for _, s := range secretListBody.Secrets {
+			secrets = append(secrets, s.Secret)
+		}
+		if !secretListBody.hasNextPage(page) {
+			return secrets, nil
+		}
+	}
+}
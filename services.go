@@ -1,20 +1,26 @@
 package main
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"github.com/urfave/cli/v2"
 )
 
-func migrateServices(*cli.Context) (err error) {
-	promptConfirm := PromptDefaultInputs()
+func migrateServices(ctx *cli.Context) (err error) {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
 		promptConfirm = true
-		migrationReq.AppId = TextInput("Please provide the application ID -")
+		migrationReq.AppId, err = TextInput("Please provide the application ID -")
+		if err != nil {
+			return err
+		}
 	}
 
-	err = MigrateEntities(promptConfirm, []string{Project}, "services", Service)
+	err = MigrateEntities(ctx.Context, promptConfirm, []string{Project}, "services", Service)
 	if err != nil {
-		log.Fatal("Failed to migrate services")
+		return fmt.Errorf("failed to migrate services: %w", err)
 	}
 	return
 }
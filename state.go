@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+)
+
+// StateDir is the folder in the current directory where the CLI keeps the files it needs across runs
+const StateDir = ".harness-upgrade"
+
+// Statuses of the steps of a migration run
+const (
+	StepQueued = "QUEUED"
+	StepDone   = "DONE"
+	StepFailed = "FAILED"
+)
+
+// Flags whose values are never written to the state file
+var secretFlags = []string{"api-key", "target-api-key"}
+
+type MigrationState struct {
+	Args      []string    `json:"args"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Steps     []StepState `json:"steps"`
+}
+
+type StepState struct {
+	Key         string       `json:"key"`
+	EntityType  EntityType   `json:"entityType"`
+	AppId       string       `json:"appId,omitempty"`
+	RequestId   string       `json:"requestId"`
+	Status      string       `json:"status"`
+	SaveSummary *SaveSummary `json:"saveSummary,omitempty"`
+}
+
+// runArgs are the arguments of the current run. They are replaced when a previous run is resumed.
+var runArgs = os.Args[1:]
+
+var state = struct {
+	sync.Mutex
+	MigrationState
+}{}
+
+func getStateFile() string {
+	return filepath.Join(StateDir, "state.json")
+}
+
+// initState loads the state of the previous run if it was for the same command & did not complete. Else the
+// state starts afresh.
+func initState() {
+	state.Lock()
+	defer state.Unlock()
+	args := sanitizeArgs(runArgs)
+	state.MigrationState = MigrationState{Args: args}
+	if migrationReq.Fresh {
+		return
+	}
+	previous, err := readState()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.WithError(err).Warn("Failed to read the state of the previous run. Starting afresh")
+		}
+		return
+	}
+	if slices.Equal(previous.Args, args) && len(previous.Steps) > 0 {
+		log.Infof("Resuming the previous run from %s. Pass --fresh to start afresh", getStateFile())
+		state.MigrationState = previous
+	}
+}
+
+func readState() (data MigrationState, err error) {
+	content, err := os.ReadFile(getStateFile())
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &data)
+	return
+}
+
+// saveState must be called with the state locked
+func saveState() {
+	state.UpdatedAt = time.Now()
+	content, err := json.MarshalIndent(state.MigrationState, "", "  ")
+	if err == nil {
+		err = MkDir(StateDir)
+	}
+	if err == nil {
+		err = WriteToFile(getStateFile(), content)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to save the state of the run")
+	}
+}
+
+// clearState removes the state file once all the steps of a run have completed
+func clearState() {
+	state.Lock()
+	defer state.Unlock()
+	if len(state.Steps) == 0 {
+		return
+	}
+	if err := os.Remove(getStateFile()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.WithError(err).Warn("Failed to remove the state file")
+	}
+	state.Steps = nil
+}
+
+func getStep(key string) (StepState, bool) {
+	state.Lock()
+	defer state.Unlock()
+	for _, step := range state.Steps {
+		if step.Key == key {
+			return step, true
+		}
+	}
+	return StepState{}, false
+}
+
+func updateStep(key string, update func(step *StepState)) {
+	state.Lock()
+	defer state.Unlock()
+	for i := range state.Steps {
+		if state.Steps[i].Key == key {
+			update(&state.Steps[i])
+			saveState()
+			return
+		}
+	}
+	step := StepState{Key: key}
+	update(&step)
+	state.Steps = append(state.Steps, step)
+	saveState()
+}
+
+func markRequestDone(reqId string, summary SaveSummary) {
+	state.Lock()
+	defer state.Unlock()
+	for i := range state.Steps {
+		if state.Steps[i].RequestId == reqId {
+			state.Steps[i].Status = StepDone
+			state.Steps[i].SaveSummary = &summary
+			saveState()
+			return
+		}
+	}
+}
+
+// getStepKey identifies a step by what is migrated & where it is migrated to
+func getStepKey(body RequestBody) string {
+	destination := body.DestinationDetails
+	destination.AuthToken = ""
+	content, _ := json.Marshal(struct {
+		Account     string
+		EntityType  EntityType
+		Filter      Filter
+		Destination DestinationDetails
+	}{migrationReq.Account, body.EntityType, body.Filter, destination})
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("%s-%x", body.EntityType, sum[:8])
+}
+
+// sanitizeArgs drops the values of secret flags & the --fresh flag from the arguments
+func sanitizeArgs(args []string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			result = append(result, args[i])
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if name == "fresh" {
+			continue
+		}
+		if slices.Contains(secretFlags, name) {
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
+
+func resumeLastRun(ctx *cli.Context) error {
+	previous, err := readState()
+	if errors.Is(err, os.ErrNotExist) {
+		return &ValidationError{Message: "There is no incomplete run to resume"}
+	}
+	if err != nil {
+		return err
+	}
+	var pending int
+	for _, step := range previous.Steps {
+		if step.Status != StepDone {
+			pending++
+		}
+	}
+	log.Infof("Resuming 'harness-upgrade %s' with %d completed & %d pending steps", strings.Join(previous.Args, " "), len(previous.Steps)-pending, pending)
+
+	// Global flags passed to resume, like the api keys that are not stored in the state file, take effect as well
+	args := os.Args[1:]
+	if i := slices.Index(args, ctx.Command.Name); i >= 0 {
+		args = args[:i]
+	}
+	runArgs = previous.Args
+	return ctx.App.RunContext(ctx.Context, append(append([]string{ctx.App.Name}, args...), previous.Args...))
+}
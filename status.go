@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func GetRequestStatus(ctx *cli.Context) error {
+	_, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	if len(migrationReq.RequestId) == 0 {
+		migrationReq.RequestId, err = TextInput("Please provide the request ID -")
+		if err != nil {
+			return err
+		}
+	}
+	kind := getOrDefault(migrationReq.RequestKind, SaveRequest)
+	if err := assertAllowedValues(kind, []string{SaveRequest, SummaryRequest}, fmt.Sprintf("Invalid kind %s. Possible values - %s, %s", kind, SaveRequest, SummaryRequest)); err != nil {
+		return err
+	}
+
+	if migrationReq.Wait {
+		if kind == SummaryRequest {
+			return pollForSummary(ctx.Context, migrationReq.RequestId)
+		}
+		return PollForCompletion(ctx.Context, migrationReq.RequestId)
+	}
+
+	var fetch func(context.Context, string) (Resource, bool, error)
+	var render func(Resource) error
+	if kind == SummaryRequest {
+		fetch, render = getSummaryResult, renderSummaryResult
+	} else {
+		fetch = getSaveResult
+		render = func(resource Resource) error {
+			return renderSaveResult(migrationReq.RequestId, resource)
+		}
+	}
+	resource, done, err := fetch(ctx.Context, migrationReq.RequestId)
+	if err != nil {
+		return err
+	}
+	if !done {
+		log.WithFields(log.Fields{
+			"requestId": migrationReq.RequestId,
+			"status":    resource.Status,
+		}).Info("The request is still being processed. Pass --wait to wait until it is done")
+		return nil
+	}
+	return render(resource)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/briandowns/spinner"
@@ -12,70 +13,110 @@ import (
 	"time"
 )
 
-func GetAccountSummary(*cli.Context) error {
-	_ = PromptEnvDetails()
-	url := GetUrl(migrationReq.Environment, MigratorService, "discover/summary/async", migrationReq.Account)
-	return handleSummary(url)
+func GetAccountSummary(ctx *cli.Context) error {
+	_, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
+	url, err := GetUrl(migrationReq.Environment, MigratorService, "discover/summary/async", migrationReq.Account)
+	if err != nil {
+		return err
+	}
+	return handleSummary(ctx.Context, url)
 }
 
-func GetAppSummary(*cli.Context) error {
-	_ = PromptEnvDetails()
+func GetAppSummary(ctx *cli.Context) error {
+	_, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
-		migrationReq.AppId = TextInput("Please provide the application ID - ")
+		migrationReq.AppId, err = TextInput("Please provide the application ID - ")
+		if err != nil {
+			return err
+		}
 	}
-	url := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "discover/summary/async", map[string]string{
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "discover/summary/async", map[string]string{
 		AccountIdentifier: migrationReq.Account,
 		"appId":           migrationReq.AppId,
 	})
-	return handleSummary(url)
+	if err != nil {
+		return err
+	}
+	return handleSummary(ctx.Context, url)
+}
+
+func handleSummary(ctx context.Context, url string) error {
+	reqId, err := queueSummary(ctx, url)
+	if err != nil {
+		return err
+	}
+	return pollForSummary(ctx, reqId)
 }
 
-func handleSummary(url string) error {
-	resp, err := Get(url, migrationReq.Auth)
+func queueSummary(ctx context.Context, url string) (string, error) {
+	resp, err := Get(ctx, url, migrationReq.Auth)
 	if err != nil {
-		log.Fatal("Failed to fetch account summary", err)
+		return "", fmt.Errorf("failed to fetch account summary: %w", err)
 	}
 	resource, err := getResource(resp.Resource)
-	if err != nil || len(resource.RequestId) == 0 {
-		log.Fatal("Failed to fetch account summary", err)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch account summary: %w", err)
+	}
+	if len(resource.RequestId) == 0 {
+		return "", &APIError{StatusCode: 200, Url: url, Message: "no request id was returned for the summary request"}
+	}
+	log.Infof("The request id is - %s", resource.RequestId)
+	return resource.RequestId, nil
+}
+
+func pollForSummary(ctx context.Context, reqId string) error {
+	resource, err := waitForSummary(ctx, reqId)
+	if err != nil {
 		return err
 	}
-	reqId := resource.RequestId
-	log.Infof("The request id is - %s", reqId)
+	return renderSummaryResult(resource)
+}
+
+func waitForSummary(ctx context.Context, reqId string) (Resource, error) {
 	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 	s.Suffix = " Processing"
 	s.Start()
+	defer s.Stop()
 	for {
-		time.Sleep(time.Second)
-		url := GetUrlWithQueryParams(migrationReq.Environment, MigratorService, "discover/summary/async-result", map[string]string{
-			AccountIdentifier: migrationReq.Account,
-			"requestId":       reqId,
-		})
-		resp, err := Get(url, migrationReq.Auth)
-		if err != nil {
+		if err := sleepWithContext(ctx, time.Second); err != nil {
 			s.Stop()
-			log.Fatal("Failed to fetch account summary", err)
+			return Resource{}, interrupted(reqId, SummaryRequest, err)
 		}
-		resource, err := getResource(resp.Resource)
+		resource, done, err := getSummaryResult(ctx, reqId)
 		if err != nil {
 			s.Stop()
-			log.Fatal("Failed to fetch account summary", err)
-		}
-		if resource.Status == "ERROR" {
-			s.Stop()
-			log.Fatal("Failed to fetch account summary", err)
-		}
-		if resource.Status == "DONE" {
-			s.Stop()
-			summary, err := getSummary(resource)
-			if err != nil {
-				s.Stop()
-				log.Fatal("Failed to fetch account summary", err)
+			if ctx.Err() != nil {
+				return resource, interrupted(reqId, SummaryRequest, ctx.Err())
 			}
-			renderSummary(summary.Summary)
-			break
+			return resource, err
+		}
+		if done {
+			return resource, nil
 		}
 	}
+}
+
+// getSummaryResult fetches the current result of a summary request & reports if it has completed
+func getSummaryResult(ctx context.Context, reqId string) (resource Resource, done bool, err error) {
+	resource, err = getAsyncResult(ctx, "discover/summary/async-result", reqId)
+	if err != nil {
+		return resource, false, fmt.Errorf("failed to fetch account summary: %w", err)
+	}
+	return resource, resource.Status == "DONE", nil
+}
+
+func renderSummaryResult(resource Resource) error {
+	summary, err := getSummary(resource)
+	if err != nil {
+		return fmt.Errorf("failed to fetch account summary: %w", err)
+	}
+	renderSummary(summary.Summary)
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
@@ -8,24 +9,30 @@ import (
 	"strconv"
 )
 
-func BulkRemoveTemplates(*cli.Context) error {
-	promptConfirm := PromptEnvDetails()
+func BulkRemoveTemplates(ctx *cli.Context) error {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
 	names := Split(migrationReq.Names, ",")
 	identifiers := Split(migrationReq.Identifiers, ",")
 
 	if migrationReq.All {
 		identifiers = []string{}
-		templates := getTemplates(migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, []string{})
+		templates, err := getTemplates(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, []string{})
+		if err != nil {
+			return err
+		}
 		for _, template := range templates {
 			identifiers = append(identifiers, template.Identifier)
 		}
 	}
 
 	if len(names) == 0 && len(identifiers) == 0 {
-		log.Fatal("No names or identifiers for the templates provided. Aborting")
+		return &ValidationError{Message: "No names or identifiers for the templates provided. Aborting"}
 	}
 	if len(names) > 0 && len(identifiers) > 0 {
-		log.Fatal("Both names and identifiers for the templates provided. Aborting")
+		return &ValidationError{Message: "Both names and identifiers for the templates provided. Aborting"}
 	}
 
 	n := len(identifiers)
@@ -35,12 +42,15 @@ func BulkRemoveTemplates(*cli.Context) error {
 	if promptConfirm {
 		confirm := ConfirmInput("Are you sure you want to proceed with deletion of " + strconv.Itoa(n) + " templates?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	if len(names) > 0 {
-		templates := getTemplates(migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, []string{})
+		templates, err := getTemplates(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, []string{})
+		if err != nil {
+			return err
+		}
 		for _, name := range names {
 			id := findTemplateIdByName(templates, name)
 			if len(id) > 0 {
@@ -50,19 +60,23 @@ func BulkRemoveTemplates(*cli.Context) error {
 		log.Debugf("Valid identifiers for the given names are - %s", identifiers)
 	}
 
-	for _, identifier := range identifiers {
-		deleteTemplate(migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, identifier, migrationReq.Force)
+	// Fetch the versions of all the templates at once instead of once per template
+	templates, err := getTemplates(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, identifiers)
+	if err != nil {
+		return err
+	}
+	versions := make(map[string][]string)
+	for _, template := range templates {
+		versions[template.Identifier] = append(versions[template.Identifier], template.VersionLabel)
 	}
+	forEachConcurrently(ctx.Context, identifiers, migrationReq.Concurrency, func(identifier string) {
+		deleteTemplate(ctx.Context, migrationReq.OrgIdentifier, migrationReq.ProjectIdentifier, identifier, versions[identifier], migrationReq.Force)
+	})
 	log.Info("Finished operation for all given templates")
 	return nil
 }
 
-func deleteTemplate(orgId string, projectId string, templateId string, force bool) {
-	templates := getTemplates(orgId, projectId, []string{templateId})
-	var versions []string
-	for _, template := range templates {
-		versions = append(versions, template.VersionLabel)
-	}
+func deleteTemplate(ctx context.Context, orgId string, projectId string, templateId string, versions []string, force bool) {
 	queryParams := map[string]string{
 		AccountIdentifier: migrationReq.Account,
 		"forceDelete":     strconv.FormatBool(force),
@@ -73,20 +87,25 @@ func deleteTemplate(orgId string, projectId string, templateId string, force boo
 	if len(projectId) > 0 {
 		queryParams[ProjectIdentifier] = projectId
 	}
-	url := GetUrlWithQueryParams(migrationReq.Environment, TemplateService, fmt.Sprintf("api/templates/%s", templateId), queryParams)
+	url, err := GetUrlWithQueryParams(migrationReq.Environment, TemplateService, fmt.Sprintf("api/templates/%s", templateId), queryParams)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to delete the template - %s", templateId)
+		return
+	}
 
 	log.Infof("Deleting the template with identifier %s", templateId)
 
-	_, err := Delete(url, migrationReq.Auth, TemplateDeleteBody{TemplateVersionLabels: versions})
+	_, err = Delete(ctx, url, migrationReq.Auth, TemplateDeleteBody{TemplateVersionLabels: versions})
 
 	if err == nil {
 		log.Infof("Successfully deleted the template - %s", templateId)
 	} else {
-		log.Errorf("Failed to delete the template - %s", templateId)
+		log.WithError(err).Errorf("Failed to delete the template - %s", templateId)
 	}
 }
 
-func getTemplates(orgId string, projectId string, templateIdentifiers []string) []TemplateDetails {
+// getTemplates fetches the templates page by page until all of them are fetched
+func getTemplates(ctx context.Context, orgId string, projectId string, templateIdentifiers []string) ([]TemplateDetails, error) {
 	queryParams := map[string]string{
 		AccountIdentifier:  migrationReq.Account,
 		"size":             "1000",
@@ -98,22 +117,34 @@ func getTemplates(orgId string, projectId string, templateIdentifiers []string)
 	if len(projectId) > 0 {
 		queryParams[ProjectIdentifier] = projectId
 	}
-	url := GetUrlWithQueryParams(migrationReq.Environment, TemplateService, "api/templates/list-metadata", queryParams)
-	resp, err := Post(url, migrationReq.Auth, FilterRequestBody{FilterType: TemplateService, TemplateIdentifiers: templateIdentifiers})
-	if err != nil || resp.Status != "SUCCESS" {
-		log.Fatal("Failed to fetch templates", err)
-	}
-	byteData, err := json.Marshal(resp.Data)
-	if err != nil {
-		log.Fatal("Failed to fetch templates", err)
-	}
-	var templateListBody TemplateListBody
-	err = json.Unmarshal(byteData, &templateListBody)
-	if err != nil {
-		log.Fatal("Failed to fetch templates", err)
+	var templates []TemplateDetails
+	for page := 0; ; page++ {
+		queryParams["page"] = strconv.Itoa(page)
+		url, err := GetUrlWithQueryParams(migrationReq.Environment, TemplateService, "api/templates/list-metadata", queryParams)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := Post(ctx, url, migrationReq.Auth, FilterRequestBody{FilterType: TemplateService, TemplateIdentifiers: templateIdentifiers})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch templates: %w", err)
+		}
+		if resp.Status != "SUCCESS" {
+			return nil, &APIError{StatusCode: 200, Url: url, Message: resp.Message, Messages: resp.Messages}
+		}
+		byteData, err := json.Marshal(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch templates: %w", err)
+		}
+		var templateListBody TemplateListBody
+		err = json.Unmarshal(byteData, &templateListBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch templates: %w", err)
+		}
+		templates = append(templates, templateListBody.Templates...)
+		if !templateListBody.hasNextPage(page) {
+			return templates, nil
+		}
 	}
-
-	return templateListBody.Templates
 }
 
 func findTemplateIdByName(templates []TemplateDetails, templateName string) string {
@@ -125,11 +156,14 @@ func findTemplateIdByName(templates []TemplateDetails, templateName string) stri
 	return ""
 }
 
-func MigrateTemplates(*cli.Context) (err error) {
-	promptConfirm := PromptDefaultInputs()
-	err = MigrateEntities(promptConfirm, []string{migrationReq.TemplateScope, migrationReq.SecretScope, migrationReq.ConnectorScope}, "templates", Template)
+func MigrateTemplates(ctx *cli.Context) (err error) {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
+	err = MigrateEntities(ctx.Context, promptConfirm, []string{migrationReq.TemplateScope, migrationReq.SecretScope, migrationReq.ConnectorScope}, "templates", Template)
 	if err != nil {
-		log.Fatal("Failed to migrate templates")
+		return fmt.Errorf("failed to migrate templates: %w", err)
 	}
 	return
 }
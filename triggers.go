@@ -1,58 +1,86 @@
 package main
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
 
-func migrateTriggers(*cli.Context) error {
-	promptConfirm := PromptDefaultInputs()
+func migrateTriggers(ctx *cli.Context) error {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
 		promptConfirm = true
-		migrationReq.AppId = TextInput("Please provide the application ID of the app containing the triggers -")
+		migrationReq.AppId, err = TextInput("Please provide the application ID of the app containing the triggers -")
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.WorkflowScope) == 0 {
 		promptConfirm = true
-		migrationReq.WorkflowScope = SelectInput("Scope for workflows:", scopes, Project)
+		migrationReq.WorkflowScope, err = SelectInput("Scope for workflows:", scopes, Project)
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.Names) == 0 && len(migrationReq.TriggerIds) == 0 && !migrationReq.All {
 		allTriggerConfirm := ConfirmInput("No triggers provided. This defaults to migrating all triggers within the application. Do you want to proceed?")
 		if !allTriggerConfirm {
 			promptConfirm = true
-			migrationReq.TriggerIds = TextInput("Provide the triggers that you wish to import as comma separated values(e.g. trigger1,trigger2)")
+			migrationReq.TriggerIds, err = TextInput("Provide the triggers that you wish to import as comma separated values(e.g. trigger1,trigger2)")
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	promptConfirm = PromptOrgAndProject([]string{Project}) || promptConfirm
+	promptConfirm, err = PromptOrgAndProject([]string{Project}, promptConfirm)
+	if err != nil {
+		return err
+	}
 
 	logMigrationDetails()
 
+	dependencies, err := getDependencyImports(ctx.Context, Trigger, len(migrationReq.TriggerIds) > 0 || len(migrationReq.Names) > 0)
+	if err != nil {
+		return err
+	}
+
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with triggers migration?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
 	// Migrating the triggers
 	var triggerIds []string
 	if len(migrationReq.TriggerIds) > 0 || len(migrationReq.Names) > 0 {
-		triggerIds, err := GetEntityIds("triggers", migrationReq.TriggerIds, migrationReq.Names)
+		triggerIds, err = GetEntityIds(ctx.Context, "triggers", migrationReq.TriggerIds, migrationReq.Names)
 		if err != nil {
-			log.Fatal("Failed to get ids of the triggers")
+			return fmt.Errorf("failed to get ids of the triggers: %w", err)
 		}
 		if len(triggerIds) == 0 {
-			log.Fatal("No triggers found with given names/ids")
+			return &ValidationError{Message: "No triggers found with given names/ids"}
 		}
 	}
 
+	if err := migrateDependencies(ctx.Context, dependencies); err != nil {
+		return err
+	}
+
 	log.Info("Importing the triggers....")
-	CreateEntities(getReqBody(Trigger, Filter{
+	err = createEntities(ctx.Context, Trigger, Filter{
 		TriggerIds: triggerIds,
 		AppId:      migrationReq.AppId,
-	}))
+	})
+	if err != nil {
+		return err
+	}
 	log.Info("Imported the triggers.")
 
 	return nil
@@ -59,6 +59,7 @@ type EntityOverrideInput struct {
 
 type OverrideFileData struct {
 	Overrides []EntityOverride `json:"overrides"`
+	Rules     []OverrideRule   `json:"rules"`
 	Settings  []Setting        `json:"settings"`
 }
 
@@ -69,6 +70,8 @@ type EntityOverride struct {
 	Identifier   *string `json:"identifier" yaml:"identifier"`
 	Name         *string `json:"name" yaml:"name"`
 	Scope        *string `json:"scope" yaml:"scope"`
+
+	line int
 }
 
 type OrgDetails struct {
@@ -122,7 +125,18 @@ type OrgBody struct {
 	Org OrgDetails `json:"organization"`
 }
 
+// NextGenPage holds the paging details of the list APIs of Next Gen
+type NextGenPage struct {
+	TotalPages int `json:"totalPages"`
+}
+
+// hasNextPage reports if there are pages after the given page index
+func (p NextGenPage) hasNextPage(page int) bool {
+	return page+1 < p.TotalPages
+}
+
 type TemplateListBody struct {
+	NextGenPage
 	Templates []TemplateDetails `json:"content"`
 }
 
@@ -134,6 +148,7 @@ type TemplateDetails struct {
 }
 
 type PipelineListBody struct {
+	NextGenPage
 	Pipelines []PipelineDetails `json:"content"`
 }
 
@@ -143,6 +158,32 @@ type PipelineDetails struct {
 	Description string `json:"description"`
 }
 
+type SecretListBody struct {
+	NextGenPage
+	Secrets []SecretBody `json:"content"`
+}
+
+type SecretBody struct {
+	Secret SecretDetails `json:"secret"`
+}
+
+type SecretDetails struct {
+	Identifier        string `json:"identifier"`
+	Name              string `json:"name"`
+	OrgIdentifier     string `json:"orgIdentifier"`
+	ProjectIdentifier string `json:"projectIdentifier"`
+}
+
+type NextGenListBody struct {
+	NextGenPage
+	Content []map[string]NextGenEntity `json:"content"`
+}
+
+type NextGenEntity struct {
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+}
+
 type FilterRequestBody struct {
 	FilterType          string   `json:"filterType"`
 	TemplateIdentifiers []string `json:"templateIdentifiers"`
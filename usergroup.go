@@ -5,8 +5,11 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func migrateUserGroups(*cli.Context) (err error) {
-	promptConfirm := PromptEnvDetails()
+func migrateUserGroups(ctx *cli.Context) (err error) {
+	promptConfirm, err := PromptEnvDetails()
+	if err != nil {
+		return err
+	}
 	log.Info("Importing the user groups....")
-	return MigrateEntities(promptConfirm, []string{migrationReq.UserGroupScope}, "usergroups", UserGroups)
+	return MigrateEntities(ctx.Context, promptConfirm, []string{migrationReq.UserGroupScope}, "usergroups", UserGroups)
 }
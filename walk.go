@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// skippedDirs are never looked into when searching for files
+var skippedDirs = []string{".git", "node_modules", StateDir}
+
+// FileFilter decides which files are looked at when walking a directory
+type FileFilter struct {
+	// Extensions of the files including the dot. Files with any extension are looked at if empty.
+	Extensions []string
+	// Include are glob patterns that files have to match one of if not empty
+	Include []string
+	// Exclude are glob patterns of files & directories that are skipped
+	Exclude []string
+	// NoGitIgnore disables skipping the files ignored by the .gitignore files found while walking
+	NoGitIgnore bool
+}
+
+// ignoreRule is a pattern from a .gitignore file
+type ignoreRule struct {
+	// base is the directory of the .gitignore file relative to the root being walked
+	base    string
+	pattern string
+	negate  bool
+	dirOnly bool
+	// anchored patterns are matched against the path relative to the base instead of the name
+	anchored bool
+	// prefix is the path of the root relative to the .gitignore file for the rules of the parent directories of the root
+	prefix string
+}
+
+// FindFiles returns the files under the roots that pass the filter. Roots that are files are always returned.
+func FindFiles(roots []string, filter FileFilter) ([]string, error) {
+	var files []string
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, root)
+			continue
+		}
+		found, err := walkDir(root, filter)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return Set(files), nil
+}
+
+func walkDir(root string, filter FileFilter) ([]string, error) {
+	var files []string
+	// Rules of every directory, including the ones inherited from the parent directories
+	rules := make(map[string][]ignoreRule)
+	if !filter.NoGitIgnore {
+		parentRules, err := loadParentGitIgnores(root)
+		if err != nil {
+			return nil, err
+		}
+		rules["."] = parentRules
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		parent := path.Dir(rel)
+
+		if d.IsDir() {
+			if rel != "." {
+				if slices.Contains(skippedDirs, d.Name()) || matchesAny(filter.Exclude, rel) || isIgnored(rules[parent], rel, true) {
+					return filepath.SkipDir
+				}
+			}
+			dirRules := rules[parent]
+			if !filter.NoGitIgnore {
+				own, err := loadGitIgnore(filepath.Join(p, ".gitignore"), rel)
+				if err != nil {
+					return err
+				}
+				dirRules = append(append([]ignoreRule{}, dirRules...), own...)
+			}
+			rules[rel] = dirRules
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 || (len(filter.Extensions) > 0 && !EndsWith(d.Name(), filter.Extensions)) {
+			return nil
+		}
+		if len(filter.Include) > 0 && !matchesAny(filter.Include, rel) {
+			return nil
+		}
+		if matchesAny(filter.Exclude, rel) || isIgnored(rules[parent], rel, false) {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	return files, err
+}
+
+// loadParentGitIgnores reads the .gitignore files of the parent directories of the root up to the root of its git
+// repository, so that the same files are skipped when walking a directory of a repository as when walking all of it
+func loadParentGitIgnores(root string) ([]ignoreRule, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	gitRoot, ok := findGitRoot(root)
+	if !ok || gitRoot == root {
+		return nil, nil
+	}
+	var rules []ignoreRule
+	for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+		prefix, err := filepath.Rel(dir, root)
+		if err != nil {
+			return nil, err
+		}
+		own, err := loadGitIgnore(filepath.Join(dir, ".gitignore"), ".")
+		if err != nil {
+			return nil, err
+		}
+		for i := range own {
+			own[i].prefix = filepath.ToSlash(prefix)
+		}
+		// The rules of the inner directories come last as they take precedence
+		rules = append(own, rules...)
+		if dir == gitRoot {
+			return rules, nil
+		}
+	}
+}
+
+// loadGitIgnore reads the rules of a .gitignore file. base is the directory of the file relative to the root.
+func loadGitIgnore(file string, base string) ([]ignoreRule, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, "\\")
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// A slash anywhere but at the end makes the pattern relative to the .gitignore file
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if len(line) == 0 {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// isIgnored reports if the path relative to the root is ignored. The last rule that matches wins.
+func isIgnored(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := path.Base(rel)
+		if rule.anchored {
+			target = rel
+			if len(rule.prefix) > 0 {
+				target = rule.prefix + "/" + rel
+			} else if rule.base != "." {
+				target = strings.TrimPrefix(rel, rule.base+"/")
+			}
+		}
+		if matchGlob(rule.pattern, target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchesAny reports if the path matches any of the patterns. Patterns without a slash are matched against the name
+// of the file or directory.
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		target := rel
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(rel)
+		}
+		if matchGlob(strings.TrimPrefix(pattern, "./"), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash separated path against a glob pattern. `**` matches any number of directories.
+func matchGlob(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// findGitRoot returns the first of the directory & its parents that holds a .git directory or file
+func findGitRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// isSameFile reports if both paths are of the same file
+func isSameFile(a string, b string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	return err == nil && os.SameFile(aInfo, bInfo)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFindFilesGitIgnore(t *testing.T) {
+	repo := t.TempDir()
+	files := map[string]string{
+		".gitignore":                       "charts/\n/services/generated\n*.bak\n",
+		"services/.gitignore":              "!keep.bak\n",
+		"services/a.yaml":                  "",
+		"services/old.bak":                 "",
+		"services/keep.bak":                "",
+		"services/charts/b.yaml":           "",
+		"services/generated/c.yaml":        "",
+		"services/nested/generated/d.yaml": "",
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		file := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter FileFilter
+		want   []string
+	}{
+		{
+			name:   "rules of the parent directories",
+			filter: FileFilter{Extensions: []string{".yaml", ".bak"}},
+			want:   []string{"a.yaml", "keep.bak", "nested/generated/d.yaml"},
+		},
+		{
+			name:   "no gitignore",
+			filter: FileFilter{Extensions: []string{".yaml", ".bak"}, NoGitIgnore: true},
+			want:   []string{"a.yaml", "charts/b.yaml", "generated/c.yaml", "keep.bak", "nested/generated/d.yaml", "old.bak"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join(repo, "services")
+			found, err := FindFiles([]string{root}, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, file := range found {
+				rel, err := filepath.Rel(root, file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
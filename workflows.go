@@ -5,23 +5,35 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func migrateWorkflows(*cli.Context) error {
-	promptConfirm := PromptDefaultInputs()
+func migrateWorkflows(ctx *cli.Context) error {
+	promptConfirm, err := PromptDefaultInputs()
+	if err != nil {
+		return err
+	}
 	if len(migrationReq.AppId) == 0 {
 		promptConfirm = true
-		migrationReq.AppId = TextInput("Please provide the application ID of the app containing the workflows -")
+		migrationReq.AppId, err = TextInput("Please provide the application ID of the app containing the workflows -")
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.WorkflowScope) == 0 {
 		promptConfirm = true
-		migrationReq.WorkflowScope = SelectInput("Scope for workflows:", scopes, Project)
+		migrationReq.WorkflowScope, err = SelectInput("Scope for workflows:", scopes, Project)
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(migrationReq.WorkflowIds) == 0 && !migrationReq.All {
 		allWorkflowConfirm := ConfirmInput("No workflows provided. This defaults to migrating all workflows within the application. Do you want to proceed?")
 		if !allWorkflowConfirm {
 			promptConfirm = true
-			migrationReq.WorkflowIds = TextInput("Provide the workflows that you wish to import as template as comma separated values(e.g. workflow1,workflow2)")
+			migrationReq.WorkflowIds, err = TextInput("Provide the workflows that you wish to import as template as comma separated values(e.g. workflow1,workflow2)")
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -29,14 +41,22 @@ func migrateWorkflows(*cli.Context) error {
 		migrationReq.PipelineScope = Project
 	}
 
-	promptConfirm = PromptOrgAndProject([]string{migrationReq.PipelineScope, migrationReq.WorkflowScope, migrationReq.SecretScope, migrationReq.ConnectorScope, migrationReq.TemplateScope}) || promptConfirm
+	promptConfirm, err = PromptOrgAndProject([]string{migrationReq.PipelineScope, migrationReq.WorkflowScope, migrationReq.SecretScope, migrationReq.ConnectorScope, migrationReq.TemplateScope}, promptConfirm)
+	if err != nil {
+		return err
+	}
 
 	logMigrationDetails()
 
+	dependencies, err := getDependencyImports(ctx.Context, Workflow, len(migrationReq.WorkflowIds) > 0)
+	if err != nil {
+		return err
+	}
+
 	if promptConfirm {
 		confirm := ConfirmInput("Do you want to proceed with workflows migration?")
 		if !confirm {
-			log.Fatal("Aborting...")
+			return &AbortedByUser{}
 		}
 	}
 
@@ -45,11 +65,18 @@ func migrateWorkflows(*cli.Context) error {
 	if len(migrationReq.WorkflowIds) > 0 {
 		workflowIds = Split(migrationReq.WorkflowIds, ",")
 	}
+	if err := migrateDependencies(ctx.Context, dependencies); err != nil {
+		return err
+	}
+
 	log.Info("Importing the workflows....")
-	CreateEntities(getReqBody(Workflow, Filter{
+	err = createEntities(ctx.Context, Workflow, Filter{
 		WorkflowIds: workflowIds,
 		AppId:       migrationReq.AppId,
-	}))
+	})
+	if err != nil {
+		return err
+	}
 	log.Info("Imported the workflows.")
 
 	return nil